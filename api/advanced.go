@@ -9,23 +9,12 @@ import (
 
 // SendGroupForwardMsg 发送群聊合并转发消息
 func SendGroupForwardMsg(b plugin.Bot, groupID int64, messages []MessageSegment) (int32, error) {
-	echo := generateEcho("send_group_forward_msg")
 	params := map[string]interface{}{
 		"group_id": groupID,
 		"messages": messages,
 	}
-	data := map[string]interface{}{
-		"action": "send_group_forward_msg",
-		"params": params,
-		"echo":   echo,
-	}
 
-	err := b.WriteJSON(data)
-	if err != nil {
-		return 0, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "send_group_forward_msg", params)
 	if err != nil {
 		return 0, err
 	}
@@ -47,23 +36,12 @@ func SendGroupForwardMsg(b plugin.Bot, groupID int64, messages []MessageSegment)
 
 // SendPrivateForwardMsg 发送私聊合并转发消息
 func SendPrivateForwardMsg(b plugin.Bot, userID int64, messages []MessageSegment) (int32, error) {
-	echo := generateEcho("send_private_forward_msg")
 	params := map[string]interface{}{
 		"user_id":  userID,
 		"messages": messages,
 	}
-	data := map[string]interface{}{
-		"action": "send_private_forward_msg",
-		"params": params,
-		"echo":   echo,
-	}
 
-	err := b.WriteJSON(data)
-	if err != nil {
-		return 0, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "send_private_forward_msg", params)
 	if err != nil {
 		return 0, err
 	}
@@ -199,19 +177,7 @@ func (f *ForwardMessageBuilder) SendToPrivate(b plugin.Bot, userID int64) (int32
 
 // GetCustomFace 获取自定义表情
 func GetCustomFace(b plugin.Bot) ([]string, error) {
-	echo := generateEcho("fetch_custom_face")
-	data := map[string]interface{}{
-		"action": "fetch_custom_face",
-		"params": map[string]interface{}{},
-		"echo":   echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "fetch_custom_face", map[string]interface{}{})
 	if err != nil {
 		return nil, err
 	}
@@ -231,21 +197,9 @@ func GetCustomFace(b plugin.Bot) ([]string, error) {
 
 // GetMFaceKey 获取商城表情 key
 func GetMFaceKey(b plugin.Bot, emojiIDs []string) ([]string, error) {
-	echo := generateEcho("fetch_mface_key")
-	data := map[string]interface{}{
-		"action": "fetch_mface_key",
-		"params": map[string]interface{}{
-			"emoji_ids": emojiIDs,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "fetch_mface_key", map[string]interface{}{
+		"emoji_ids": emojiIDs,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -265,23 +219,11 @@ func GetMFaceKey(b plugin.Bot, emojiIDs []string) ([]string, error) {
 
 // JoinGroupEmojiChain 加入群聊表情接龙
 func JoinGroupEmojiChain(b plugin.Bot, groupID int64, messageID int32, emojiID int32) error {
-	echo := generateEcho(".join_group_emoji_chain")
-	data := map[string]interface{}{
-		"action": ".join_group_emoji_chain",
-		"params": map[string]interface{}{
-			"group_id":   groupID,
-			"message_id": messageID,
-			"emoji_id":   emojiID,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, ".join_group_emoji_chain", map[string]interface{}{
+		"group_id":   groupID,
+		"message_id": messageID,
+		"emoji_id":   emojiID,
+	})
 	if err != nil {
 		return err
 	}
@@ -295,23 +237,11 @@ func JoinGroupEmojiChain(b plugin.Bot, groupID int64, messageID int32, emojiID i
 
 // JoinFriendEmojiChain 加入好友表情接龙
 func JoinFriendEmojiChain(b plugin.Bot, userID int64, messageID int32, emojiID int32) error {
-	echo := generateEcho(".join_friend_emoji_chain")
-	data := map[string]interface{}{
-		"action": ".join_friend_emoji_chain",
-		"params": map[string]interface{}{
-			"user_id":    userID,
-			"message_id": messageID,
-			"emoji_id":   emojiID,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, ".join_friend_emoji_chain", map[string]interface{}{
+		"user_id":    userID,
+		"message_id": messageID,
+		"emoji_id":   emojiID,
+	})
 	if err != nil {
 		return err
 	}
@@ -338,22 +268,10 @@ type AICharacterGroup struct {
 
 // GetAICharacters 获取群 AI 语音可用声色列表
 func GetAICharacters(b plugin.Bot, groupID int64, chatType int) ([]AICharacterGroup, error) {
-	echo := generateEcho("get_ai_characters")
-	data := map[string]interface{}{
-		"action": "get_ai_characters",
-		"params": map[string]interface{}{
-			"group_id":  groupID,
-			"chat_type": chatType, // 1: 朗读, 2: 说唱
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_ai_characters", map[string]interface{}{
+		"group_id":  groupID,
+		"chat_type": chatType, // 1: 朗读, 2: 说唱
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -373,23 +291,11 @@ func GetAICharacters(b plugin.Bot, groupID int64, chatType int) ([]AICharacterGr
 
 // SendGroupAIVoice 发送群 AI 语音
 func SendGroupAIVoice(b plugin.Bot, groupID int64, characterID string, text string) (int32, error) {
-	echo := generateEcho("send_group_ai_voice")
-	data := map[string]interface{}{
-		"action": "send_group_ai_voice",
-		"params": map[string]interface{}{
-			"group_id":     groupID,
-			"character_id": characterID,
-			"text":         text,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return 0, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "send_group_ai_voice", map[string]interface{}{
+		"group_id":     groupID,
+		"character_id": characterID,
+		"text":         text,
+	})
 	if err != nil {
 		return 0, err
 	}
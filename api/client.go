@@ -7,8 +7,23 @@ import (
 	"github.com/iamlibie/milonra-go/plugin"
 )
 
-// SendGroupMessage 发送群消息
+// SendGroupMessage 发送群消息，超长消息按 ConfigureLongMessage 的设置拆分或合并转发，
+// 拆分发送时返回第一条消息的ID
 func SendGroupMessage(b plugin.Bot, groupID int64, message interface{}) (int32, error) {
+	parts, forward := prepareLongMessage(b, message)
+	if forward != nil {
+		return SendGroupForwardMsg(b, groupID, forward)
+	}
+	if parts != nil {
+		return sendParts(parts, func(part interface{}) (int32, error) {
+			return sendGroupMessage(b, groupID, part)
+		})
+	}
+	return sendGroupMessage(b, groupID, message)
+}
+
+// sendGroupMessage 发送一条群消息
+func sendGroupMessage(b plugin.Bot, groupID int64, message interface{}) (int32, error) {
 	// 支持字符串、Message对象或MessageSegment数组
 	var msg interface{}
 	switch v := message.(type) {
@@ -22,22 +37,10 @@ func SendGroupMessage(b plugin.Bot, groupID int64, message interface{}) (int32,
 		msg = fmt.Sprintf("%v", message)
 	}
 
-	echo := generateEcho("send_group_msg")
-	data := map[string]interface{}{
-		"action": "send_group_msg",
-		"params": map[string]interface{}{
-			"group_id": groupID,
-			"message":  msg,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return 0, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "send_group_msg", map[string]interface{}{
+		"group_id": groupID,
+		"message":  msg,
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -57,8 +60,22 @@ func SendGroupMessage(b plugin.Bot, groupID int64, message interface{}) (int32,
 	return result.MessageID, nil
 }
 
-// SendPrivateMessage 发送私聊消息
+// SendPrivateMessage 发送私聊消息，超长消息的处理同 SendGroupMessage
 func SendPrivateMessage(b plugin.Bot, userID int64, message interface{}) (int32, error) {
+	parts, forward := prepareLongMessage(b, message)
+	if forward != nil {
+		return SendPrivateForwardMsg(b, userID, forward)
+	}
+	if parts != nil {
+		return sendParts(parts, func(part interface{}) (int32, error) {
+			return sendPrivateMessage(b, userID, part)
+		})
+	}
+	return sendPrivateMessage(b, userID, message)
+}
+
+// sendPrivateMessage 发送一条私聊消息
+func sendPrivateMessage(b plugin.Bot, userID int64, message interface{}) (int32, error) {
 	var msg interface{}
 	switch v := message.(type) {
 	case string:
@@ -71,22 +88,10 @@ func SendPrivateMessage(b plugin.Bot, userID int64, message interface{}) (int32,
 		msg = fmt.Sprintf("%v", message)
 	}
 
-	echo := generateEcho("send_private_msg")
-	data := map[string]interface{}{
-		"action": "send_private_msg",
-		"params": map[string]interface{}{
-			"user_id": userID,
-			"message": msg,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return 0, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "send_private_msg", map[string]interface{}{
+		"user_id": userID,
+		"message": msg,
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -120,7 +125,6 @@ func SendMsg(b plugin.Bot, messageType string, userID, groupID int64, message in
 		msg = fmt.Sprintf("%v", message)
 	}
 
-	echo := generateEcho("send_msg")
 	params := map[string]interface{}{
 		"message": msg,
 	}
@@ -135,18 +139,7 @@ func SendMsg(b plugin.Bot, messageType string, userID, groupID int64, message in
 		params["group_id"] = groupID
 	}
 
-	data := map[string]interface{}{
-		"action": "send_msg",
-		"params": params,
-		"echo":   echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return 0, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "send_msg", params)
 	if err != nil {
 		return 0, err
 	}
@@ -168,21 +161,9 @@ func SendMsg(b plugin.Bot, messageType string, userID, groupID int64, message in
 
 // DeleteMsg 撤回消息
 func DeleteMsg(b plugin.Bot, messageID int32) error {
-	echo := generateEcho("delete_msg")
-	data := map[string]interface{}{
-		"action": "delete_msg",
-		"params": map[string]interface{}{
-			"message_id": messageID,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "delete_msg", map[string]interface{}{
+		"message_id": messageID,
+	})
 	if err != nil {
 		return err
 	}
@@ -196,21 +177,9 @@ func DeleteMsg(b plugin.Bot, messageID int32) error {
 
 // GetMsg 获取消息
 func GetMsg(b plugin.Bot, messageID int32) (*MessageInfo, error) {
-	echo := generateEcho("get_msg")
-	data := map[string]interface{}{
-		"action": "get_msg",
-		"params": map[string]interface{}{
-			"message_id": messageID,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_msg", map[string]interface{}{
+		"message_id": messageID,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -230,21 +199,9 @@ func GetMsg(b plugin.Bot, messageID int32) (*MessageInfo, error) {
 
 // GetForwardMsg 获取合并转发消息
 func GetForwardMsg(b plugin.Bot, id string) (*ForwardMessage, error) {
-	echo := generateEcho("get_forward_msg")
-	data := map[string]interface{}{
-		"action": "get_forward_msg",
-		"params": map[string]interface{}{
-			"id": id,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_forward_msg", map[string]interface{}{
+		"id": id,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -271,22 +228,10 @@ func SendLike(b plugin.Bot, userID int64, times int) error {
 		times = 10
 	}
 
-	echo := generateEcho("send_like")
-	data := map[string]interface{}{
-		"action": "send_like",
-		"params": map[string]interface{}{
-			"user_id": userID,
-			"times":   times,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "send_like", map[string]interface{}{
+		"user_id": userID,
+		"times":   times,
+	})
 	if err != nil {
 		return err
 	}
@@ -300,26 +245,10 @@ func SendLike(b plugin.Bot, userID int64, times int) error {
 
 // GetStrangerInfo 获取陌生人信息
 func GetStrangerInfo(b plugin.Bot, userID int64) (*StrangerInfo, error) {
-	// 生成唯一 echo
-	echo := generateEcho("get_stranger_info")
-
-	// 构造请求数据
-	data := map[string]interface{}{
-		"action": "get_stranger_info",
-		"params": map[string]interface{}{
-			"user_id": userID,
-		},
-		"echo": echo,
-	}
-
-	// 发送请求
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, fmt.Errorf("发送请求失败: %v", err)
-	}
-
-	// 等待响应
-	resp, err := responseWaiter.WaitForResponse(echo)
+	// 发送请求并等待响应
+	resp, err := callAPI(b, "get_stranger_info", map[string]interface{}{
+		"user_id": userID,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -341,22 +270,10 @@ func GetStrangerInfo(b plugin.Bot, userID int64) (*StrangerInfo, error) {
 
 // GetGroupInfo 获取群信息
 func GetGroupInfo(b plugin.Bot, groupID int64) (*GroupInfo, error) {
-	echo := generateEcho("get_group_info")
-
-	data := map[string]interface{}{
-		"action": "get_group_info",
-		"params": map[string]interface{}{
-			"group_id": groupID,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, fmt.Errorf("发送请求失败: %v", err)
-	}
 
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_group_info", map[string]interface{}{
+		"group_id": groupID,
+	})
 	if err != nil {
 		return nil, err
 	}
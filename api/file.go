@@ -10,7 +10,6 @@ import (
 
 // UploadGroupFile 上传群文件
 func UploadGroupFile(b plugin.Bot, groupID int64, file, name string, folder ...string) error {
-	echo := generateEcho("upload_group_file")
 	params := map[string]interface{}{
 		"group_id": groupID,
 		"file":     file,
@@ -23,18 +22,7 @@ func UploadGroupFile(b plugin.Bot, groupID int64, file, name string, folder ...s
 		params["folder"] = "/"
 	}
 
-	data := map[string]interface{}{
-		"action": "upload_group_file",
-		"params": params,
-		"echo":   echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "upload_group_file", params)
 	if err != nil {
 		return err
 	}
@@ -48,23 +36,11 @@ func UploadGroupFile(b plugin.Bot, groupID int64, file, name string, folder ...s
 
 // UploadPrivateFile 上传私聊文件
 func UploadPrivateFile(b plugin.Bot, userID int64, file, name string) error {
-	echo := generateEcho("upload_private_file")
-	data := map[string]interface{}{
-		"action": "upload_private_file",
-		"params": map[string]interface{}{
-			"user_id": userID,
-			"file":    file,
-			"name":    name,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "upload_private_file", map[string]interface{}{
+		"user_id": userID,
+		"file":    file,
+		"name":    name,
+	})
 	if err != nil {
 		return err
 	}
@@ -78,7 +54,6 @@ func UploadPrivateFile(b plugin.Bot, userID int64, file, name string) error {
 
 // GetGroupFileURL 获取群文件资源链接
 func GetGroupFileURL(b plugin.Bot, groupID int64, fileID string, busid ...int) (string, error) {
-	echo := generateEcho("get_group_file_url")
 	params := map[string]interface{}{
 		"group_id": groupID,
 		"file_id":  fileID,
@@ -88,18 +63,7 @@ func GetGroupFileURL(b plugin.Bot, groupID int64, fileID string, busid ...int) (
 		params["busid"] = busid[0]
 	}
 
-	data := map[string]interface{}{
-		"action": "get_group_file_url",
-		"params": params,
-		"echo":   echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return "", err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_group_file_url", params)
 	if err != nil {
 		return "", err
 	}
@@ -121,7 +85,6 @@ func GetGroupFileURL(b plugin.Bot, groupID int64, fileID string, busid ...int) (
 
 // GetPrivateFileURL 获取私聊文件资源链接
 func GetPrivateFileURL(b plugin.Bot, userID int64, fileID string, fileHash ...string) (string, error) {
-	echo := generateEcho("get_private_file_url")
 	params := map[string]interface{}{
 		"user_id": userID,
 		"file_id": fileID,
@@ -131,18 +94,7 @@ func GetPrivateFileURL(b plugin.Bot, userID int64, fileID string, fileHash ...st
 		params["file_hash"] = fileHash[0]
 	}
 
-	data := map[string]interface{}{
-		"action": "get_private_file_url",
-		"params": params,
-		"echo":   echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return "", err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_private_file_url", params)
 	if err != nil {
 		return "", err
 	}
@@ -188,21 +140,9 @@ type GroupFolderInfo struct {
 
 // GetGroupRootFiles 获取群根目录文件列表
 func GetGroupRootFiles(b plugin.Bot, groupID int64) (*GroupFilesData, error) {
-	echo := generateEcho("get_group_root_files")
-	data := map[string]interface{}{
-		"action": "get_group_root_files",
-		"params": map[string]interface{}{
-			"group_id": groupID,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_group_root_files", map[string]interface{}{
+		"group_id": groupID,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -228,22 +168,10 @@ type GroupFilesData struct {
 
 // GetGroupFilesByFolder 获取群子目录文件列表
 func GetGroupFilesByFolder(b plugin.Bot, groupID int64, folderID string) (*GroupFilesData, error) {
-	echo := generateEcho("get_group_files_by_folder")
-	data := map[string]interface{}{
-		"action": "get_group_files_by_folder",
-		"params": map[string]interface{}{
-			"group_id":  groupID,
-			"folder_id": folderID,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_group_files_by_folder", map[string]interface{}{
+		"group_id":  groupID,
+		"folder_id": folderID,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -263,23 +191,11 @@ func GetGroupFilesByFolder(b plugin.Bot, groupID int64, folderID string) (*Group
 
 // CreateGroupFileFolder 创建群文件文件夹（只能在根目录创建）
 func CreateGroupFileFolder(b plugin.Bot, groupID int64, name string) error {
-	echo := generateEcho("create_group_file_folder")
-	data := map[string]interface{}{
-		"action": "create_group_file_folder",
-		"params": map[string]interface{}{
-			"group_id":  groupID,
-			"name":      name,
-			"parent_id": "/", // TX不再允许在非根目录创建文件夹
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "create_group_file_folder", map[string]interface{}{
+		"group_id":  groupID,
+		"name":      name,
+		"parent_id": "/", // TX不再允许在非根目录创建文件夹
+	})
 	if err != nil {
 		return err
 	}
@@ -293,22 +209,10 @@ func CreateGroupFileFolder(b plugin.Bot, groupID int64, name string) error {
 
 // DeleteGroupFile 删除群文件
 func DeleteGroupFile(b plugin.Bot, groupID int64, fileID string) error {
-	echo := generateEcho("delete_group_file")
-	data := map[string]interface{}{
-		"action": "delete_group_file",
-		"params": map[string]interface{}{
-			"group_id": groupID,
-			"file_id":  fileID,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "delete_group_file", map[string]interface{}{
+		"group_id": groupID,
+		"file_id":  fileID,
+	})
 	if err != nil {
 		return err
 	}
@@ -322,22 +226,10 @@ func DeleteGroupFile(b plugin.Bot, groupID int64, fileID string) error {
 
 // DeleteGroupFileFolder 删除群文件文件夹
 func DeleteGroupFileFolder(b plugin.Bot, groupID int64, folderID string) error {
-	echo := generateEcho("delete_group_file_folder")
-	data := map[string]interface{}{
-		"action": "delete_group_file_folder",
-		"params": map[string]interface{}{
-			"group_id":  groupID,
-			"folder_id": folderID,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "delete_group_file_folder", map[string]interface{}{
+		"group_id":  groupID,
+		"folder_id": folderID,
+	})
 	if err != nil {
 		return err
 	}
@@ -351,24 +243,12 @@ func DeleteGroupFileFolder(b plugin.Bot, groupID int64, folderID string) error {
 
 // MoveGroupFile 移动群文件
 func MoveGroupFile(b plugin.Bot, groupID int64, fileID, parentDir, targetDir string) error {
-	echo := generateEcho("move_group_file")
-	data := map[string]interface{}{
-		"action": "move_group_file",
-		"params": map[string]interface{}{
-			"group_id":         groupID,
-			"file_id":          fileID,
-			"parent_directory": parentDir,
-			"target_directory": targetDir,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "move_group_file", map[string]interface{}{
+		"group_id":         groupID,
+		"file_id":          fileID,
+		"parent_directory": parentDir,
+		"target_directory": targetDir,
+	})
 	if err != nil {
 		return err
 	}
@@ -382,23 +262,11 @@ func MoveGroupFile(b plugin.Bot, groupID int64, fileID, parentDir, targetDir str
 
 // RenameGroupFileFolder 重命名群文件文件夹
 func RenameGroupFileFolder(b plugin.Bot, groupID int64, folderID, newName string) error {
-	echo := generateEcho("rename_group_file_folder")
-	data := map[string]interface{}{
-		"action": "rename_group_file_folder",
-		"params": map[string]interface{}{
-			"group_id":        groupID,
-			"folder_id":       folderID,
-			"new_folder_name": newName,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "rename_group_file_folder", map[string]interface{}{
+		"group_id":        groupID,
+		"folder_id":       folderID,
+		"new_folder_name": newName,
+	})
 	if err != nil {
 		return err
 	}
@@ -412,21 +280,9 @@ func RenameGroupFileFolder(b plugin.Bot, groupID int64, folderID, newName string
 
 // UploadImage 上传图片
 func UploadImage(b plugin.Bot, file string) (string, error) {
-	echo := generateEcho("upload_image")
-	data := map[string]interface{}{
-		"action": "upload_image",
-		"params": map[string]interface{}{
-			"file": file,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return "", err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "upload_image", map[string]interface{}{
+		"file": file,
+	})
 	if err != nil {
 		return "", err
 	}
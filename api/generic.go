@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/iamlibie/milonra-go/plugin"
@@ -28,22 +29,11 @@ type OCRResult struct {
 
 // OCRImage 图像OCR识别
 func OCRImage(b plugin.Bot, image string) (*OCRResult, error) {
-	echo := generateEcho("ocr_image")
 	params := map[string]interface{}{
 		"image": image,
 	}
-	data := map[string]interface{}{
-		"action": "ocr_image",
-		"params": params,
-		"echo":   echo,
-	}
 
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, fmt.Errorf("发送请求失败: %v", err)
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "ocr_image", params)
 	if err != nil {
 		return nil, err
 	}
@@ -62,30 +52,22 @@ func OCRImage(b plugin.Bot, image string) (*OCRResult, error) {
 
 // SendGroupPoke 发送群聊戳一戳
 func SendGroupPoke(b plugin.Bot, groupID int64, userID int64) error {
-	echo := generateEcho("group_poke")
 	params := map[string]interface{}{
 		"group_id": groupID,
 		"user_id":  userID,
 	}
-	data := map[string]interface{}{
-		"action": "group_poke",
-		"params": params,
-		"echo":   echo,
-	}
 
-	err := b.WriteJSON(data)
+	resp, err := callAPI(b, "group_poke", params)
+	if errors.Is(err, ErrTimeout) {
+		return err
+	}
 	if err != nil {
-		// 如果失败，尝试使用消息方式
+		// 如果请求发送失败，尝试使用消息方式
 		msg := NewMessage().Poke(userID)
 		_, err2 := SendGroupMessage(b, groupID, msg)
 		return err2
 	}
 
-	resp, err := responseWaiter.WaitForResponse(echo)
-	if err != nil {
-		return err
-	}
-
 	if resp.Status != "ok" {
 		return fmt.Errorf("戳一戳失败: %s", resp.Status)
 	}
@@ -95,29 +77,21 @@ func SendGroupPoke(b plugin.Bot, groupID int64, userID int64) error {
 
 // SendPrivatePoke 发送私聊戳一戳
 func SendPrivatePoke(b plugin.Bot, userID int64, targetID int64) error {
-	echo := generateEcho("friend_poke")
 	params := map[string]interface{}{
 		"user_id": targetID,
 	}
-	data := map[string]interface{}{
-		"action": "friend_poke",
-		"params": params,
-		"echo":   echo,
-	}
 
-	err := b.WriteJSON(data)
+	resp, err := callAPI(b, "friend_poke", params)
+	if errors.Is(err, ErrTimeout) {
+		return err
+	}
 	if err != nil {
-		// 如果失败，尝试使用消息方式
+		// 如果请求发送失败，尝试使用消息方式
 		msg := NewMessage().Poke(targetID)
 		_, err2 := SendPrivateMessage(b, targetID, msg)
 		return err2
 	}
 
-	resp, err := responseWaiter.WaitForResponse(echo)
-	if err != nil {
-		return err
-	}
-
 	if resp.Status != "ok" {
 		return fmt.Errorf("戳一戳失败: %s", resp.Status)
 	}
@@ -9,23 +9,11 @@ import (
 
 // SetGroupKick 群组踢人
 func SetGroupKick(b plugin.Bot, groupID, userID int64, rejectAddRequest bool) error {
-	echo := generateEcho("set_group_kick")
-	data := map[string]interface{}{
-		"action": "set_group_kick",
-		"params": map[string]interface{}{
-			"group_id":           groupID,
-			"user_id":            userID,
-			"reject_add_request": rejectAddRequest,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "set_group_kick", map[string]interface{}{
+		"group_id":           groupID,
+		"user_id":            userID,
+		"reject_add_request": rejectAddRequest,
+	})
 	if err != nil {
 		return err
 	}
@@ -39,23 +27,11 @@ func SetGroupKick(b plugin.Bot, groupID, userID int64, rejectAddRequest bool) er
 
 // SetGroupBan 群组单人禁言
 func SetGroupBan(b plugin.Bot, groupID, userID int64, duration int) error {
-	echo := generateEcho("set_group_ban")
-	data := map[string]interface{}{
-		"action": "set_group_ban",
-		"params": map[string]interface{}{
-			"group_id": groupID,
-			"user_id":  userID,
-			"duration": duration,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "set_group_ban", map[string]interface{}{
+		"group_id": groupID,
+		"user_id":  userID,
+		"duration": duration,
+	})
 	if err != nil {
 		return err
 	}
@@ -69,23 +45,11 @@ func SetGroupBan(b plugin.Bot, groupID, userID int64, duration int) error {
 
 // SetGroupAnonymousBan 群组匿名用户禁言
 func SetGroupAnonymousBan(b plugin.Bot, groupID string, flag string, duration int) error {
-	echo := generateEcho("set_group_anonymous_ban")
-	data := map[string]interface{}{
-		"action": "set_group_anonymous_ban",
-		"params": map[string]interface{}{
-			"group_id": groupID,
-			"flag":     flag,
-			"duration": duration,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "set_group_anonymous_ban", map[string]interface{}{
+		"group_id": groupID,
+		"flag":     flag,
+		"duration": duration,
+	})
 	if err != nil {
 		return err
 	}
@@ -99,22 +63,10 @@ func SetGroupAnonymousBan(b plugin.Bot, groupID string, flag string, duration in
 
 // SetGroupWholeBan 群组全员禁言
 func SetGroupWholeBan(b plugin.Bot, groupID int64, enable bool) error {
-	echo := generateEcho("set_group_whole_ban")
-	data := map[string]interface{}{
-		"action": "set_group_whole_ban",
-		"params": map[string]interface{}{
-			"group_id": groupID,
-			"enable":   enable,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "set_group_whole_ban", map[string]interface{}{
+		"group_id": groupID,
+		"enable":   enable,
+	})
 	if err != nil {
 		return err
 	}
@@ -128,23 +80,11 @@ func SetGroupWholeBan(b plugin.Bot, groupID int64, enable bool) error {
 
 // SetGroupAdmin 设置群管理员
 func SetGroupAdmin(b plugin.Bot, groupID, userID int64, enable bool) error {
-	echo := generateEcho("set_group_admin")
-	data := map[string]interface{}{
-		"action": "set_group_admin",
-		"params": map[string]interface{}{
-			"group_id": groupID,
-			"user_id":  userID,
-			"enable":   enable,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "set_group_admin", map[string]interface{}{
+		"group_id": groupID,
+		"user_id":  userID,
+		"enable":   enable,
+	})
 	if err != nil {
 		return err
 	}
@@ -158,22 +98,10 @@ func SetGroupAdmin(b plugin.Bot, groupID, userID int64, enable bool) error {
 
 // SetGroupAnonymous 群组匿名
 func SetGroupAnonymous(b plugin.Bot, groupID int64, enable bool) error {
-	echo := generateEcho("set_group_anonymous")
-	data := map[string]interface{}{
-		"action": "set_group_anonymous",
-		"params": map[string]interface{}{
-			"group_id": groupID,
-			"enable":   enable,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "set_group_anonymous", map[string]interface{}{
+		"group_id": groupID,
+		"enable":   enable,
+	})
 	if err != nil {
 		return err
 	}
@@ -187,23 +115,11 @@ func SetGroupAnonymous(b plugin.Bot, groupID int64, enable bool) error {
 
 // SetGroupCard 设置群名片
 func SetGroupCard(b plugin.Bot, groupID, userID int64, card string) error {
-	echo := generateEcho("set_group_card")
-	data := map[string]interface{}{
-		"action": "set_group_card",
-		"params": map[string]interface{}{
-			"group_id": groupID,
-			"user_id":  userID,
-			"card":     card,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "set_group_card", map[string]interface{}{
+		"group_id": groupID,
+		"user_id":  userID,
+		"card":     card,
+	})
 	if err != nil {
 		return err
 	}
@@ -217,22 +133,10 @@ func SetGroupCard(b plugin.Bot, groupID, userID int64, card string) error {
 
 // SetGroupName 设置群名
 func SetGroupName(b plugin.Bot, groupID int64, name string) error {
-	echo := generateEcho("set_group_name")
-	data := map[string]interface{}{
-		"action": "set_group_name",
-		"params": map[string]interface{}{
-			"group_id":   groupID,
-			"group_name": name,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "set_group_name", map[string]interface{}{
+		"group_id":   groupID,
+		"group_name": name,
+	})
 	if err != nil {
 		return err
 	}
@@ -246,22 +150,10 @@ func SetGroupName(b plugin.Bot, groupID int64, name string) error {
 
 // SetGroupLeave 退群
 func SetGroupLeave(b plugin.Bot, groupID int64, isDismiss bool) error {
-	echo := generateEcho("set_group_leave")
-	data := map[string]interface{}{
-		"action": "set_group_leave",
-		"params": map[string]interface{}{
-			"group_id":   groupID,
-			"is_dismiss": isDismiss,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "set_group_leave", map[string]interface{}{
+		"group_id":   groupID,
+		"is_dismiss": isDismiss,
+	})
 	if err != nil {
 		return err
 	}
@@ -275,24 +167,12 @@ func SetGroupLeave(b plugin.Bot, groupID int64, isDismiss bool) error {
 
 // SetGroupSpecialTitle 设置群专属头衔
 func SetGroupSpecialTitle(b plugin.Bot, groupID, userID int64, specialTitle string, duration int) error {
-	echo := generateEcho("set_group_special_title")
-	data := map[string]interface{}{
-		"action": "set_group_special_title",
-		"params": map[string]interface{}{
-			"group_id":      groupID,
-			"user_id":       userID,
-			"special_title": specialTitle,
-			"duration":      duration,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "set_group_special_title", map[string]interface{}{
+		"group_id":      groupID,
+		"user_id":       userID,
+		"special_title": specialTitle,
+		"duration":      duration,
+	})
 	if err != nil {
 		return err
 	}
@@ -306,23 +186,11 @@ func SetGroupSpecialTitle(b plugin.Bot, groupID, userID int64, specialTitle stri
 
 // GetGroupMemberInfo 获取群成员信息
 func GetGroupMemberInfo(b plugin.Bot, groupID, userID int64, noCache bool) (*GroupMemberInfo, error) {
-	echo := generateEcho("get_group_member_info")
-	data := map[string]interface{}{
-		"action": "get_group_member_info",
-		"params": map[string]interface{}{
-			"group_id": groupID,
-			"user_id":  userID,
-			"no_cache": noCache,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_group_member_info", map[string]interface{}{
+		"group_id": groupID,
+		"user_id":  userID,
+		"no_cache": noCache,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// HTTPTransport 通过 OneBot 实现的 HTTP API 发送请求，用于无法建立 WebSocket 连接的部署
+//
+// 每次 API 调用以 POST <URL>/<action> 发送参数，HTTP 响应即为 API 响应，
+// 因此 WriteJSON 返回时响应已经交给等待者（见 Responses），可以直接作为 plugin.Bot 的写入方式使用（见 bot.Bot.Transport）
+type HTTPTransport struct {
+	URL         string       // OneBot HTTP API 地址，如 http://localhost:5700
+	AccessToken string       // 访问令牌，不为空时通过 Authorization 请求头发送
+	Client      *http.Client // 发送请求使用的客户端，nil 时使用默认客户端，超时由 API 调用的超时时间决定
+
+	responsesOnce sync.Once
+	responses     *ResponseWaiter
+}
+
+// Responses 返回接收该 Transport 响应的等待器
+func (t *HTTPTransport) Responses() *ResponseWaiter {
+	t.responsesOnce.Do(func() {
+		t.responses = NewResponseWaiter()
+	})
+	return t.responses
+}
+
+// 默认的 HTTP 客户端，不设置超时，请求的截止时间由 API 调用的超时时间决定（见 SetDefaultTimeout、WithTimeout）
+var defaultHTTPClient = &http.Client{}
+
+// ContextWriter 发送请求时可以感知上下文的写入方式（如 HTTPTransport），
+// API 调用会优先通过它发送，使调用被取消时（插件超时、服务停止等）正在发送的请求也随之结束
+type ContextWriter interface {
+	WriteJSONContext(ctx context.Context, v interface{}) error
+}
+
+// WriteJSON 将 OneBot API 请求（action、params、echo）以 HTTP POST 发送，并把响应交给等待该 echo 的调用者
+func (t *HTTPTransport) WriteJSON(v interface{}) error {
+	return t.WriteJSONContext(context.Background(), v)
+}
+
+// WriteJSONContext 与 WriteJSON 相同，ctx 取消时中止 HTTP 请求
+func (t *HTTPTransport) WriteJSONContext(ctx context.Context, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var request struct {
+		Action string          `json:"action"`
+		Params json.RawMessage `json:"params"`
+		Echo   interface{}     `json:"echo"`
+	}
+	if err := json.Unmarshal(data, &request); err != nil {
+		return err
+	}
+	if request.Action == "" {
+		return errors.New("缺少 action")
+	}
+	body := []byte(request.Params)
+	if len(body) == 0 {
+		body = []byte("{}")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(t.URL, "/")+"/"+request.Action, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.AccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+t.AccessToken)
+	}
+
+	client := t.Client
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("调用 %s 失败: HTTP %d", request.Action, resp.StatusCode)
+	}
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("解析 %s 的响应失败: %w", request.Action, err)
+	}
+	if _, ok := result["status"]; !ok {
+		return fmt.Errorf("%s 的响应缺少 status", request.Action)
+	}
+	result["echo"] = request.Echo
+	t.Responses().HandleAPIResponse(result)
+	return nil
+}
@@ -0,0 +1,162 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/iamlibie/milonra-go/api"
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+// transportBot sends API calls through an HTTPTransport
+type transportBot struct {
+	*api.HTTPTransport
+}
+
+func (b transportBot) GetSelfID() int64 { return 10001 }
+
+func TestHTTPTransport(t *testing.T) {
+	type request struct {
+		path   string
+		token  string
+		params map[string]interface{}
+	}
+	requests := make(chan request, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&params)
+		requests <- request{r.URL.Path, r.Header.Get("Authorization"), params}
+
+		switch r.URL.Path {
+		case "/send_group_msg":
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "retcode": 0, "data": map[string]interface{}{"message_id": 42}})
+		case "/delete_msg":
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "failed", "retcode": 100})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	b := transportBot{&api.HTTPTransport{URL: server.URL + "/", AccessToken: "secret"}}
+
+	id, err := api.SendGroupMessage(b, 123, "hello")
+	if err != nil || id != 42 {
+		t.Fatalf("SendGroupMessage = %d, %v, want 42", id, err)
+	}
+	req := <-requests
+	if req.path != "/send_group_msg" || req.token != "Bearer secret" {
+		t.Errorf("Unexpected request: %+v", req)
+	}
+	if req.params["group_id"] != float64(123) || req.params["message"] != "hello" {
+		t.Errorf("Unexpected params: %v", req.params)
+	}
+
+	// OneBot 返回失败状态
+	if err := api.DeleteMsg(b, 1); err == nil {
+		t.Error("Expected failed status to be reported")
+	}
+	<-requests
+
+	// HTTP 错误直接返回，不等待响应超时
+	if _, err := api.GetGroupInfo(b, 123); err == nil {
+		t.Error("Expected HTTP 404 to be reported")
+	}
+}
+
+func TestHTTPTransportCancel(t *testing.T) {
+	aborted := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 不返回响应，直到请求被取消（读完请求体后服务端才能察觉连接关闭）
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+		aborted <- struct{}{}
+	}))
+	defer server.Close()
+
+	b := transportBot{&api.HTTPTransport{URL: server.URL}}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := api.GetLoginInfo(plugin.WithContext(b, ctx))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("HTTP call was not aborted promptly: %v", time.Since(start))
+	}
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Error("Expected the HTTP request to be cancelled")
+	}
+}
+
+func TestHTTPTransportTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	b := transportBot{&api.HTTPTransport{URL: server.URL}}
+
+	start := time.Now()
+	_, err := api.GetLoginInfo(api.WithTimeout(b, 50*time.Millisecond))
+	if !errors.Is(err, api.ErrTimeout) {
+		t.Errorf("Expected api.ErrTimeout, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Per-call timeout was not applied to the HTTP request: %v", time.Since(start))
+	}
+}
+
+// deadlineRecorder records the deadline of every request sent through it
+type deadlineRecorder struct {
+	next      http.RoundTripper
+	deadlines chan time.Time
+}
+
+func (d deadlineRecorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	deadline, _ := r.Context().Deadline()
+	d.deadlines <- deadline
+	return d.next.RoundTrip(r)
+}
+
+func TestHTTPTransportTimeoutNotCapped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "retcode": 0, "data": map[string]interface{}{"user_id": 10001}})
+	}))
+	defer server.Close()
+
+	// 默认客户端使用 http.DefaultTransport，记录实际发出的请求的截止时间
+	recorder := deadlineRecorder{next: http.DefaultTransport, deadlines: make(chan time.Time, 1)}
+	http.DefaultTransport = recorder
+	defer func() { http.DefaultTransport = recorder.next }()
+
+	b := transportBot{&api.HTTPTransport{URL: server.URL}}
+
+	// 超过 30 秒的超时时间不会被默认客户端截短
+	start := time.Now()
+	if _, err := api.GetLoginInfo(api.WithTimeout(b, 45*time.Second)); err != nil {
+		t.Fatalf("GetLoginInfo failed: %v", err)
+	}
+	if deadline := <-recorder.deadlines; deadline.Sub(start) < 40*time.Second {
+		t.Errorf("Expected the request deadline to follow the 45s timeout, got %v", deadline.Sub(start))
+	}
+
+	// 0 表示不限制，请求没有截止时间
+	if _, err := api.GetLoginInfo(api.WithTimeout(b, 0)); err != nil {
+		t.Fatalf("GetLoginInfo failed: %v", err)
+	}
+	if deadline := <-recorder.deadlines; !deadline.IsZero() {
+		t.Errorf("Expected no request deadline without a timeout, got %v", time.Until(deadline))
+	}
+}
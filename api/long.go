@@ -0,0 +1,277 @@
+package api
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+// LongMessageMode 超长消息的处理方式
+type LongMessageMode string
+
+const (
+	LongMessageNone    LongMessageMode = ""        // 不处理，原样发送
+	LongMessageSplit   LongMessageMode = "split"   // 按行拆分为多条消息依次发送，条数过多时改用合并转发
+	LongMessageForward LongMessageMode = "forward" // 包装为一条合并转发消息
+)
+
+// LongMessageConfig 超长消息配置，对 SendGroupMessage 和 SendPrivateMessage 生效
+type LongMessageConfig struct {
+	Mode      LongMessageMode `json:"mode"`       // 处理方式，默认不处理
+	MaxLength int             `json:"max_length"` // 单条消息最多包含的字符数，默认 1500
+	MaxParts  int             `json:"max_parts"`  // 拆分模式下最多拆分为多少条，超过时改用合并转发，默认 5，负数表示不限制
+	Nickname  string          `json:"nickname"`   // 合并转发消息中显示的发送者昵称，默认 "Milonra"
+}
+
+var (
+	longMessage   LongMessageConfig
+	longMessageMu sync.RWMutex
+)
+
+// replyPrefix 字符串消息开头的引用回复 CQ 码
+var replyPrefix = regexp.MustCompile(`^\[CQ:reply,[^\]]*\]`)
+
+// cqCodePattern 字符串消息中的 CQ 码，第一个分组为类型
+var cqCodePattern = regexp.MustCompile(`\[CQ:([^,\]]+)[^\]]*\]`)
+
+// inlineCQTypes 可以与文本一起按行拆分的 CQ 码类型，其他类型（图片、语音等）的消息超长时改用合并转发
+var inlineCQTypes = map[string]bool{"reply": true, "at": true, "face": true}
+
+// ConfigureLongMessage 设置超长消息的处理方式，可以在运行时重新调用，未设置的字段使用默认值
+func ConfigureLongMessage(c LongMessageConfig) {
+	if c.MaxLength <= 0 {
+		c.MaxLength = 1500
+	}
+	if c.MaxParts == 0 {
+		c.MaxParts = 5
+	}
+	if c.Nickname == "" {
+		c.Nickname = "Milonra"
+	}
+
+	longMessageMu.Lock()
+	defer longMessageMu.Unlock()
+	longMessage = c
+}
+
+// maxMessageLength 返回启用超长消息处理时单条消息的最大字符数，未启用时返回0
+func maxMessageLength() int {
+	longMessageMu.RLock()
+	defer longMessageMu.RUnlock()
+	if longMessage.Mode == LongMessageNone {
+		return 0
+	}
+	return longMessage.MaxLength
+}
+
+// prepareLongMessage 按配置处理超长消息：需要拆分时返回依次发送的各条消息，
+// 需要合并转发时返回转发节点，都不需要时均返回 nil
+func prepareLongMessage(b plugin.Bot, message interface{}) (parts []interface{}, forward []MessageSegment) {
+	longMessageMu.RLock()
+	config := longMessage
+	longMessageMu.RUnlock()
+
+	if config.Mode == LongMessageNone || messageLength(message) <= config.MaxLength {
+		return nil, nil
+	}
+
+	text, isText := message.(string)
+	splittable := isText && !hasMedia(text)
+	if config.Mode == LongMessageSplit && splittable {
+		chunks := splitText(text, config.MaxLength)
+		if config.MaxParts < 0 || len(chunks) <= config.MaxParts {
+			parts = make([]interface{}, len(chunks))
+			for i, chunk := range chunks {
+				parts[i] = chunk
+			}
+			return parts, nil
+		}
+	}
+
+	// 合并转发：文本按长度拆分为多个节点，其他消息作为一个节点，转发消息中不能引用回复
+	builder := NewForwardMessage()
+	switch {
+	case splittable:
+		for _, chunk := range splitText(replyPrefix.ReplaceAllString(text, ""), config.MaxLength) {
+			builder.AddNode(b.GetSelfID(), config.Nickname, textContent(chunk))
+		}
+	case isText:
+		builder.AddNode(b.GetSelfID(), config.Nickname, textContent(replyPrefix.ReplaceAllString(text, "")))
+	default:
+		builder.AddNode(b.GetSelfID(), config.Nickname, withoutReply(message))
+	}
+	return nil, builder.Build()
+}
+
+// messageLength 返回消息中文本的字符数，CQ 码不计入
+func messageLength(message interface{}) int {
+	var segments []MessageSegment
+	switch v := message.(type) {
+	case string:
+		length := 0
+		for _, p := range splitCQ(v) {
+			if !p.code {
+				length += utf8.RuneCountInString(p.text)
+			}
+		}
+		return length
+	case *Message:
+		segments = v.Build()
+	case []MessageSegment:
+		segments = v
+	}
+
+	length := 0
+	for _, seg := range segments {
+		if text, ok := seg.Data["text"].(string); ok && seg.Type == "text" {
+			length += utf8.RuneCountInString(text)
+		}
+	}
+	return length
+}
+
+// withoutReply 移除消息段中的引用回复
+func withoutReply(message interface{}) interface{} {
+	var segments []MessageSegment
+	switch v := message.(type) {
+	case *Message:
+		segments = v.Build()
+	case []MessageSegment:
+		segments = v
+	default:
+		return message
+	}
+
+	result := make([]MessageSegment, 0, len(segments))
+	for _, seg := range segments {
+		if seg.Type != "reply" {
+			result = append(result, seg)
+		}
+	}
+	return result
+}
+
+// cqPiece 字符串消息中的一段文本或一个 CQ 码
+type cqPiece struct {
+	text string
+	code bool
+}
+
+// splitCQ 将字符串消息拆分为文本和 CQ 码
+func splitCQ(text string) []cqPiece {
+	var pieces []cqPiece
+	last := 0
+	for _, loc := range cqCodePattern.FindAllStringIndex(text, -1) {
+		if loc[0] > last {
+			pieces = append(pieces, cqPiece{text: text[last:loc[0]]})
+		}
+		pieces = append(pieces, cqPiece{text: text[loc[0]:loc[1]], code: true})
+		last = loc[1]
+	}
+	if last < len(text) {
+		pieces = append(pieces, cqPiece{text: text[last:]})
+	}
+	return pieces
+}
+
+// textContent 将字符串消息转换为转发节点的内容，包含 CQ 码时解析为消息段，否则节点会把 CQ 码当作文本显示
+func textContent(text string) interface{} {
+	if cqCodePattern.MatchString(text) {
+		return ParseCQCode(text)
+	}
+	return text
+}
+
+// hasMedia 判断字符串消息是否包含图片、语音等不能按行拆分的 CQ 码
+func hasMedia(text string) bool {
+	for _, match := range cqCodePattern.FindAllStringSubmatch(text, -1) {
+		if !inlineCQTypes[match[1]] {
+			return true
+		}
+	}
+	return false
+}
+
+// splitText 按行拆分文本，每段最多 max 个字符（CQ 码不计入），单行超长时按字符截断，不会拆开 CQ 码
+func splitText(text string, max int) []string {
+	// 按换行分组，CQ 码留在所在的行中
+	var lines [][]cqPiece
+	var line []cqPiece
+	for _, p := range splitCQ(text) {
+		if p.code {
+			line = append(line, p)
+			continue
+		}
+		for _, s := range strings.SplitAfter(p.text, "\n") {
+			if s == "" {
+				continue
+			}
+			line = append(line, cqPiece{text: s})
+			if strings.HasSuffix(s, "\n") {
+				lines, line = append(lines, line), nil
+			}
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+
+	var parts []string
+	var current strings.Builder
+	length := 0
+	flush := func() {
+		if part := strings.TrimRight(current.String(), "\n"); part != "" {
+			parts = append(parts, part)
+		}
+		current.Reset()
+		length = 0
+	}
+
+	for _, line := range lines {
+		lineLength := 0
+		for _, p := range line {
+			if !p.code {
+				lineLength += utf8.RuneCountInString(p.text)
+			}
+		}
+		if length > 0 && length+lineLength > max {
+			flush()
+		}
+		for _, p := range line {
+			if p.code {
+				current.WriteString(p.text)
+				continue
+			}
+			runes := []rune(p.text)
+			for len(runes) > 0 {
+				if length >= max {
+					flush()
+				}
+				n := min(max-length, len(runes))
+				current.WriteString(string(runes[:n]))
+				length += n
+				runes = runes[n:]
+			}
+		}
+	}
+	flush()
+	return parts
+}
+
+// sendParts 依次发送拆分后的消息，返回第一条消息的ID
+func sendParts(parts []interface{}, send func(message interface{}) (int32, error)) (int32, error) {
+	var firstID int32
+	for i, part := range parts {
+		id, err := send(part)
+		if err != nil {
+			return firstID, err
+		}
+		if i == 0 {
+			firstID = id
+		}
+	}
+	return firstID, nil
+}
@@ -0,0 +1,138 @@
+package api_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/iamlibie/milonra-go/api"
+)
+
+func TestLongMessageSplit(t *testing.T) {
+	api.ConfigureLongMessage(api.LongMessageConfig{Mode: api.LongMessageSplit, MaxLength: 10, MaxParts: 3})
+	defer api.ConfigureLongMessage(api.LongMessageConfig{})
+
+	bot := &recordingBot{}
+	id, err := api.SendGroupMessage(bot, 123, "line one\nline two\nthree")
+	if err != nil {
+		t.Fatalf("SendGroupMessage failed: %v", err)
+	}
+	if id != 1 {
+		t.Errorf("Expected the first part's message id 1, got %d", id)
+	}
+	messages, _ := bot.sent()
+	want := []interface{}{"line one", "line two", "three"}
+	if len(messages) != len(want) {
+		t.Fatalf("Expected %d parts, got %v", len(want), messages)
+	}
+	for i := range want {
+		if messages[i] != want[i] {
+			t.Errorf("Part %d: expected %q, got %q", i, want[i], messages[i])
+		}
+	}
+
+	// 单行超长时按字符截断
+	bot = &recordingBot{}
+	if _, err := api.SendPrivateMessage(bot, 456, strings.Repeat("字", 25)); err != nil {
+		t.Fatalf("SendPrivateMessage failed: %v", err)
+	}
+	if messages, _ := bot.sent(); len(messages) != 3 || messages[2] != strings.Repeat("字", 5) {
+		t.Errorf("Unexpected parts: %v", messages)
+	}
+
+	// 短消息原样发送
+	bot = &recordingBot{}
+	api.SendGroupMessage(bot, 123, "short")
+	if messages, _ := bot.sent(); len(messages) != 1 || messages[0] != "short" {
+		t.Errorf("Expected short message to be sent as is, got %v", messages)
+	}
+}
+
+func TestLongMessageForward(t *testing.T) {
+	api.ConfigureLongMessage(api.LongMessageConfig{Mode: api.LongMessageSplit, MaxLength: 10, MaxParts: 2})
+	defer api.ConfigureLongMessage(api.LongMessageConfig{})
+
+	// 拆分条数超过 MaxParts 时改用合并转发
+	bot := &recordingBot{}
+	if _, err := api.SendGroupMessage(bot, 123, "[CQ:reply,id=7]aaaaaaaa\nbbbbbbbb\ncccccccc"); err != nil {
+		t.Fatalf("SendGroupMessage failed: %v", err)
+	}
+	bot.mu.Lock()
+	actions := append([]string(nil), bot.actions...)
+	bot.mu.Unlock()
+	messages, _ := bot.sent()
+	if len(actions) != 1 || actions[0] != "send_group_forward_msg" {
+		t.Fatalf("Expected a single forward message, got %v", actions)
+	}
+	nodes := messages[0].([]api.MessageSegment)
+	if len(nodes) != 3 {
+		t.Fatalf("Expected 3 forward nodes, got %d", len(nodes))
+	}
+	content := nodes[0].Data["content"].([]api.MessageSegment)
+	if content[0].Data["text"] != "aaaaaaaa" || nodes[0].Data["user_id"] != int64(10001) {
+		t.Errorf("Unexpected first node: %+v", nodes[0])
+	}
+
+	// 消息段超长时整体作为一个节点转发，并移除引用回复
+	api.ConfigureLongMessage(api.LongMessageConfig{Mode: api.LongMessageForward, MaxLength: 10})
+	bot = &recordingBot{}
+	msg := api.NewMessage().Reply(7).Text(strings.Repeat("x", 20)).Image("a.png")
+	if _, err := api.SendPrivateMessage(bot, 456, msg); err != nil {
+		t.Fatalf("SendPrivateMessage failed: %v", err)
+	}
+	messages, _ = bot.sent()
+	nodes = messages[0].([]api.MessageSegment)
+	content = nodes[0].Data["content"].([]api.MessageSegment)
+	if len(nodes) != 1 || len(content) != 2 || content[0].Type != "text" || content[1].Type != "image" {
+		t.Errorf("Unexpected forward node: %+v", nodes)
+	}
+}
+
+func TestLongMessageCQCodes(t *testing.T) {
+	api.ConfigureLongMessage(api.LongMessageConfig{Mode: api.LongMessageSplit, MaxLength: 100, MaxParts: 3})
+	defer api.ConfigureLongMessage(api.LongMessageConfig{})
+
+	// CQ 码不计入长度，短文本加图片原样发送
+	image := "[CQ:image,file=base64://" + strings.Repeat("A", 300) + "]"
+	bot := &recordingBot{}
+	if _, err := api.SendGroupMessage(bot, 123, "看图"+image); err != nil {
+		t.Fatalf("SendGroupMessage failed: %v", err)
+	}
+	if messages, _ := bot.sent(); len(messages) != 1 || messages[0] != "看图"+image {
+		t.Errorf("Expected image message to be sent as is, got %d messages", len(messages))
+	}
+
+	// 拆分时不会拆开 CQ 码
+	api.ConfigureLongMessage(api.LongMessageConfig{Mode: api.LongMessageSplit, MaxLength: 10, MaxParts: 5})
+	bot = &recordingBot{}
+	api.SendGroupMessage(bot, 123, "[CQ:reply,id=7][CQ:at,qq=10002] "+strings.Repeat("x", 12)+"[CQ:face,id=1]yy")
+	messages, _ := bot.sent()
+	want := []interface{}{"[CQ:reply,id=7][CQ:at,qq=10002] " + strings.Repeat("x", 9), "xxx[CQ:face,id=1]yy"}
+	if len(messages) != len(want) {
+		t.Fatalf("Expected %d parts, got %v", len(want), messages)
+	}
+	for i := range want {
+		if messages[i] != want[i] {
+			t.Errorf("Part %d: expected %q, got %q", i, want[i], messages[i])
+		}
+	}
+
+	// 包含图片等非文本内容的超长消息改用合并转发，整体作为一个节点
+	bot = &recordingBot{}
+	api.SendGroupMessage(bot, 123, "[CQ:reply,id=7]"+strings.Repeat("z", 30)+image)
+	bot.mu.Lock()
+	actions := append([]string(nil), bot.actions...)
+	bot.mu.Unlock()
+	if len(actions) != 1 || actions[0] != "send_group_forward_msg" {
+		t.Fatalf("Expected a single forward message, got %v", actions)
+	}
+	messages, _ = bot.sent()
+	nodes := messages[0].([]api.MessageSegment)
+	if len(nodes) != 1 {
+		t.Fatalf("Expected 1 forward node, got %d", len(nodes))
+	}
+	content := nodes[0].Data["content"].([]api.MessageSegment)
+	if len(content) != 2 || content[0].Data["text"] != strings.Repeat("z", 30) ||
+		content[1].Type != "image" || content[1].Data["file"] != "base64://"+strings.Repeat("A", 300) {
+		t.Errorf("Unexpected forward node content: %+v", content)
+	}
+}
@@ -4,13 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"github.com/iamlibie/milonra-go/event"
 )
 
 // MessageSegment 消息段
-type MessageSegment struct {
-	Type string                 `json:"type"`
-	Data map[string]interface{} `json:"data"`
-}
+type MessageSegment = event.MessageSegment
 
 // Message 消息构造器
 type Message struct {
@@ -0,0 +1,317 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iamlibie/milonra-go/plugin"
+	"github.com/iamlibie/milonra-go/ratelimit"
+)
+
+// ErrSendQueueFull 发往同一会话的排队消息过多
+var ErrSendQueueFull = errors.New("发送队列已满")
+
+// maxCoalescedLength 合并后的文本最多包含的字符数
+const maxCoalescedLength = 3000
+
+// SendQueueConfig 发送队列配置，用于限制发送频率，避免触发平台风控
+//
+// 启用后发往同一会话的消息按调用顺序依次发送，排队中的连续文本消息会被合并为一条，
+// 合并后只有第一条消息的调用得到发送的消息ID，被合并的调用得到的消息ID为0
+type SendQueueConfig struct {
+	Enabled        bool          `json:"enabled"`         // 是否启用，未启用时消息立即发送
+	GlobalRate     float64       `json:"global_rate"`     // 所有会话每秒最多发送的消息数，默认 2，负数表示不限制
+	GlobalBurst    int           `json:"global_burst"`    // 允许连续发送的消息数，默认 5
+	TargetInterval time.Duration `json:"target_interval"` // 同一会话两条消息的最小间隔，默认 1秒，负数表示不限制
+	MaxCoalesce    int           `json:"max_coalesce"`    // 最多合并多少条排队中的文本消息，默认 5，1 或负数表示不合并
+	MaxPending     int           `json:"max_pending"`     // 每个会话最多排队的消息数，默认 100，负数表示不限制
+}
+
+// SendQueueStats 发送队列状态
+type SendQueueStats struct {
+	Pending   int    `json:"pending"`   // 排队中的消息数
+	Targets   int    `json:"targets"`   // 有消息排队的会话数
+	Sent      uint64 `json:"sent"`      // 已发送的消息数（合并后的一条只计一次）
+	Coalesced uint64 `json:"coalesced"` // 被合并到其他消息中发送的消息数
+}
+
+// sendJob 一次排队中的发送请求
+type sendJob struct {
+	ctx    context.Context
+	bot    plugin.Bot
+	action string
+	params map[string]interface{}
+	done   chan sendResult
+}
+
+type sendResult struct {
+	resp *APIResponse
+	err  error
+}
+
+// targetQueue 发往同一会话的消息队列
+type targetQueue struct {
+	jobs []*sendJob
+	last time.Time // 上一次发送的时间
+}
+
+// sendQueue 发送调度器，每个有消息排队的会话对应一个发送协程
+type sendQueue struct {
+	mu        sync.Mutex
+	config    SendQueueConfig
+	global    *ratelimit.Limiter
+	targets   map[string]*targetQueue
+	pending   int
+	sent      uint64
+	coalesced uint64
+}
+
+var outbox = &sendQueue{targets: make(map[string]*targetQueue)}
+
+// ConfigureSendQueue 设置发送队列，可以在运行时重新调用，未设置的字段使用默认值
+func ConfigureSendQueue(c SendQueueConfig) {
+	if c.GlobalRate == 0 {
+		c.GlobalRate = 2
+	}
+	if c.GlobalBurst <= 0 {
+		c.GlobalBurst = 5
+	}
+	if c.TargetInterval == 0 {
+		c.TargetInterval = time.Second
+	}
+	if c.MaxCoalesce == 0 {
+		c.MaxCoalesce = 5
+	}
+	if c.MaxPending == 0 {
+		c.MaxPending = 100
+	}
+
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+
+	outbox.config = c
+	outbox.global = nil
+	if c.GlobalRate > 0 {
+		outbox.global = ratelimit.NewLimiter(c.GlobalRate, c.GlobalBurst)
+	}
+}
+
+// GetSendQueueStats 返回发送队列的当前状态
+func GetSendQueueStats() SendQueueStats {
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+
+	return SendQueueStats{
+		Pending:   outbox.pending,
+		Targets:   len(outbox.targets),
+		Sent:      outbox.sent,
+		Coalesced: outbox.coalesced,
+	}
+}
+
+// sendTarget 返回发送类 API 的目标会话，不同机器人账号发往同一会话的消息分别排队，其他 API 返回 false
+func sendTarget(selfID int64, action string, params map[string]interface{}) (string, bool) {
+	switch action {
+	case "send_msg", "send_group_msg", "send_private_msg", "send_group_forward_msg", "send_private_forward_msg":
+	default:
+		return "", false
+	}
+	if id, ok := params["group_id"].(int64); ok && id != 0 {
+		return fmt.Sprintf("%d/group:%d", selfID, id), true
+	}
+	if id, ok := params["user_id"].(int64); ok && id != 0 {
+		return fmt.Sprintf("%d/private:%d", selfID, id), true
+	}
+	return "", false
+}
+
+// enabled 发送队列是否启用
+func (q *sendQueue) enabled() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.config.Enabled
+}
+
+// send 将消息加入目标会话的队列，并等待发送完成或 ctx 取消
+func (q *sendQueue) send(ctx context.Context, b plugin.Bot, target, action string, params map[string]interface{}) (*APIResponse, error) {
+	job := &sendJob{
+		ctx:    ctx,
+		bot:    b,
+		action: action,
+		params: params,
+		done:   make(chan sendResult, 1),
+	}
+
+	q.mu.Lock()
+	tq, running := q.targets[target]
+	if !running {
+		tq = &targetQueue{}
+		q.targets[target] = tq
+	}
+	if q.config.MaxPending > 0 && len(tq.jobs) >= q.config.MaxPending {
+		q.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrSendQueueFull, target)
+	}
+	tq.jobs = append(tq.jobs, job)
+	q.pending++
+	q.mu.Unlock()
+
+	if !running {
+		go q.run(target, tq)
+	}
+
+	select {
+	case result := <-job.done:
+		return result.resp, result.err
+	case <-ctx.Done():
+		// 仍在排队中的消息会被发送协程跳过
+		return nil, fmt.Errorf("等待发送被取消: %s: %w", action, ctx.Err())
+	}
+}
+
+// run 依次发送某个会话排队中的消息，队列为空且超过发送间隔后退出
+func (q *sendQueue) run(target string, tq *targetQueue) {
+	for {
+		q.mu.Lock()
+		if len(tq.jobs) == 0 {
+			// 在发送间隔内保留队列，使紧接着到达的消息仍然遵守间隔
+			if remaining := time.Until(tq.last.Add(q.config.TargetInterval)); remaining > 0 {
+				q.mu.Unlock()
+				time.Sleep(remaining)
+				continue
+			}
+			delete(q.targets, target)
+			q.mu.Unlock()
+			return
+		}
+		q.mu.Unlock()
+
+		q.wait(tq)
+
+		q.mu.Lock()
+		jobs := q.next(tq)
+		q.mu.Unlock()
+		if len(jobs) == 0 {
+			continue
+		}
+
+		first := jobs[0]
+		params := first.params
+		if len(jobs) > 1 {
+			params = coalesceParams(jobs)
+		}
+		// 消息已经出队，即使调用方不再等待也要发送完成，保证顺序
+		resp, err := call(context.WithoutCancel(first.ctx), first.bot, first.action, params)
+		for i, job := range jobs {
+			result := sendResult{resp: resp, err: err}
+			if i > 0 {
+				result.resp = coalescedResponse(resp)
+			}
+			job.done <- result
+		}
+
+		q.mu.Lock()
+		tq.last = time.Now()
+		q.sent++
+		q.coalesced += uint64(len(jobs) - 1)
+		q.mu.Unlock()
+	}
+}
+
+// wait 等待会话的发送间隔和全局发送频率允许发送下一条消息
+func (q *sendQueue) wait(tq *targetQueue) {
+	q.mu.Lock()
+	interval, last := q.config.TargetInterval, tq.last
+	q.mu.Unlock()
+	if interval > 0 && !last.IsZero() {
+		time.Sleep(time.Until(last.Add(interval)))
+	}
+
+	for {
+		q.mu.Lock()
+		global := q.global
+		q.mu.Unlock()
+		if global == nil {
+			return
+		}
+		retryAfter, ok := global.Allow("")
+		if ok {
+			return
+		}
+		time.Sleep(retryAfter)
+	}
+}
+
+// next 取出下一批要发送的消息，跳过已取消的消息，并合并排队中连续的文本消息，调用前需持有锁
+func (q *sendQueue) next(tq *targetQueue) []*sendJob {
+	var jobs []*sendJob
+	length, maxLength := 0, maxCoalescedLength
+	if limit := maxMessageLength(); limit > 0 {
+		// 合并后不能超过超长消息的限制，否则拆分后的消息会被重新合并
+		maxLength = min(maxLength, limit)
+	}
+	for len(tq.jobs) > 0 {
+		job := tq.jobs[0]
+		if job.ctx.Err() == nil {
+			text, ok := coalescable(job, len(jobs) == 0)
+			if len(jobs) > 0 && (!ok || job.action != jobs[0].action ||
+				len(jobs) >= q.config.MaxCoalesce || length+len([]rune(text))+1 > maxLength) {
+				break
+			}
+			jobs = append(jobs, job)
+			length += len([]rune(text))
+			if !ok {
+				// 非文本消息单独发送
+				tq.jobs = tq.jobs[1:]
+				q.pending--
+				break
+			}
+		}
+		tq.jobs = tq.jobs[1:]
+		q.pending--
+	}
+	return jobs
+}
+
+// coalescable 返回可以合并的纯文本消息，带引用回复的消息只能作为合并后的第一条
+func coalescable(job *sendJob, first bool) (string, bool) {
+	text, ok := job.params["message"].(string)
+	if !ok || text == "" {
+		return "", false
+	}
+	if !first && strings.HasPrefix(text, "[CQ:reply,") {
+		return "", false
+	}
+	return text, true
+}
+
+// coalescedResponse 被合并到其他消息中发送的调用没有自己的消息，返回消息ID为0的响应，
+// 避免不同插件得到同一个消息ID后撤回或引用了别人的消息
+func coalescedResponse(resp *APIResponse) *APIResponse {
+	if resp == nil {
+		return nil
+	}
+	merged := *resp
+	merged.Data = json.RawMessage(`{"message_id":0}`)
+	return &merged
+}
+
+// coalesceParams 将多条文本消息合并为一条，消息之间换行分隔
+func coalesceParams(jobs []*sendJob) map[string]interface{} {
+	texts := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		texts = append(texts, job.params["message"].(string))
+	}
+
+	params := make(map[string]interface{}, len(jobs[0].params))
+	for k, v := range jobs[0].params {
+		params[k] = v
+	}
+	params["message"] = strings.Join(texts, "\n")
+	return params
+}
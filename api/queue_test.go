@@ -0,0 +1,121 @@
+package api_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iamlibie/milonra-go/api"
+)
+
+// recordingBot records every sent message and answers immediately
+type recordingBot struct {
+	mu       sync.Mutex
+	actions  []string
+	messages []interface{}
+	times    []time.Time
+}
+
+func (b *recordingBot) WriteJSON(v interface{}) error {
+	data := v.(map[string]interface{})
+	params := data["params"].(map[string]interface{})
+
+	b.mu.Lock()
+	b.actions = append(b.actions, data["action"].(string))
+	if messages, ok := params["messages"]; ok {
+		b.messages = append(b.messages, messages)
+	} else {
+		b.messages = append(b.messages, params["message"])
+	}
+	b.times = append(b.times, time.Now())
+	id := len(b.messages)
+	b.mu.Unlock()
+
+	api.HandleAPIResponse(map[string]interface{}{
+		"status":  "ok",
+		"retcode": float64(0),
+		"data":    map[string]interface{}{"message_id": float64(id)},
+		"echo":    data["echo"],
+	})
+	return nil
+}
+
+func (b *recordingBot) GetSelfID() int64 { return 10001 }
+
+func (b *recordingBot) sent() ([]interface{}, []time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]interface{}(nil), b.messages...), append([]time.Time(nil), b.times...)
+}
+
+func TestSendQueue(t *testing.T) {
+	api.ConfigureSendQueue(api.SendQueueConfig{
+		Enabled:        true,
+		GlobalRate:     -1,
+		TargetInterval: 100 * time.Millisecond,
+	})
+	defer api.ConfigureSendQueue(api.SendQueueConfig{})
+
+	bot := &recordingBot{}
+	before := api.GetSendQueueStats()
+	if _, err := api.SendGroupMessage(bot, 123, "first"); err != nil {
+		t.Fatalf("SendGroupMessage failed: %v", err)
+	}
+
+	// 发送间隔内到达的消息按顺序排队，连续的文本消息合并为一条
+	var wg sync.WaitGroup
+	ids := make([]int32, 3)
+	for i, text := range []string{"a", "b", "c"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := api.SendGroupMessage(bot, 123, text)
+			if err != nil {
+				t.Errorf("SendGroupMessage failed: %v", err)
+			}
+			ids[i] = id
+		}()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if stats := api.GetSendQueueStats(); stats.Pending != 3 || stats.Targets != 1 {
+		t.Errorf("Expected 3 pending messages for 1 target, got %+v", stats)
+	}
+	wg.Wait()
+
+	// 非文本消息不会被合并
+	segments := api.NewMessage().Text("segments").Build()
+	if _, err := api.SendGroupMessage(bot, 123, segments); err != nil {
+		t.Fatalf("SendGroupMessage failed: %v", err)
+	}
+
+	messages, times := bot.sent()
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 sends, got %d: %v", len(messages), messages)
+	}
+	if messages[0] != "first" || messages[1] != "a\nb\nc" {
+		t.Errorf("Unexpected messages: %v", messages)
+	}
+	if _, ok := messages[2].([]api.MessageSegment); !ok {
+		t.Errorf("Expected segments to be sent separately, got %v", messages[2])
+	}
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < 90*time.Millisecond {
+			t.Errorf("Send %d came %v after the previous one", i, gap)
+		}
+	}
+	if ids[0] != 2 || ids[1] != 0 || ids[2] != 0 {
+		t.Errorf("Expected only the first coalesced message to get message id 2, got %v", ids)
+	}
+	if stats := api.GetSendQueueStats(); stats.Pending != 0 || stats.Sent-before.Sent != 3 || stats.Coalesced-before.Coalesced != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	// 不同会话互不阻塞
+	start := time.Now()
+	if _, err := api.SendPrivateMessage(bot, 456, "other"); err != nil {
+		t.Fatalf("SendPrivateMessage failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Send to another target waited %v", elapsed)
+	}
+}
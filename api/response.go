@@ -1,12 +1,24 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/iamlibie/milonra-go/logging"
+	"github.com/iamlibie/milonra-go/plugin"
 )
 
+// ErrTimeout 等待 API 响应超时
+var ErrTimeout = errors.New("等待响应超时")
+
+// ErrConnectionClosed 发出请求的连接已经断开，不会再收到响应
+var ErrConnectionClosed = errors.New("连接已断开")
+
 // APIResponse OneBot API 响应结构
 type APIResponse struct {
 	Status  string          `json:"status"`
@@ -31,42 +43,121 @@ type GroupInfo struct {
 	MaxMemberCount int64  `json:"max_member_count"`
 }
 
-// ResponseWaiter 响应等待器
+// ResponseWaiter 响应等待器，记录一个连接上等待响应的请求
+//
+// 每个连接应使用自己的等待器（见 NewResponseWaiter），连接断开时调用 Close 使等待中的请求立即失败
 type ResponseWaiter struct {
 	mu      sync.RWMutex
 	waiters map[string]chan *APIResponse
-	timeout time.Duration
+
+	closeOnce sync.Once
+	closed    chan struct{}
 }
 
-// 全局响应等待器
-var responseWaiter = &ResponseWaiter{
-	waiters: make(map[string]chan *APIResponse),
-	timeout: 30 * time.Second, // 30秒超时
+// NewResponseWaiter 创建响应等待器
+func NewResponseWaiter() *ResponseWaiter {
+	return &ResponseWaiter{
+		waiters: make(map[string]chan *APIResponse),
+		closed:  make(chan struct{}),
+	}
+}
+
+// 全局响应等待器，用于没有自己的等待器的 Bot（见 Responder）
+var responseWaiter = NewResponseWaiter()
+
+// 默认的响应超时时间（纳秒）
+var defaultTimeout atomic.Int64
+
+func init() {
+	defaultTimeout.Store(int64(30 * time.Second))
+}
+
+// SetDefaultTimeout 设置 API 调用（发送请求和等待响应）的默认超时时间，默认30秒，d <= 0 时恢复默认值
+func SetDefaultTimeout(d time.Duration) {
+	if d <= 0 {
+		d = 30 * time.Second
+	}
+	defaultTimeout.Store(int64(d))
 }
 
 // WaitForResponse 等待指定 echo 的响应
 func (rw *ResponseWaiter) WaitForResponse(echo string) (*APIResponse, error) {
-	// 创建响应通道
+	respChan := rw.register(echo)
+	defer rw.unregister(echo)
+	ctx := context.Background()
+	return rw.wait(ctx, echo, respChan, deadlineOf(ctx))
+}
+
+// Close 使所有等待中和之后的请求立即以 ErrConnectionClosed 失败，连接断开时调用，可以重复调用
+func (rw *ResponseWaiter) Close() {
+	rw.closeOnce.Do(func() {
+		close(rw.closed)
+	})
+}
+
+// Pending 返回等待响应的请求数
+func (rw *ResponseWaiter) Pending() int {
+	rw.mu.RLock()
+	defer rw.mu.RUnlock()
+	return len(rw.waiters)
+}
+
+// register 注册 echo 对应的响应通道
+func (rw *ResponseWaiter) register(echo string) chan *APIResponse {
+	// 带缓冲，避免响应到达时等待者已经退出导致阻塞
 	respChan := make(chan *APIResponse, 1)
 
 	rw.mu.Lock()
 	rw.waiters[echo] = respChan
 	rw.mu.Unlock()
 
-	// 确保清理
-	defer func() {
-		rw.mu.Lock()
-		delete(rw.waiters, echo)
-		rw.mu.Unlock()
-		close(respChan)
-	}()
+	return respChan
+}
+
+// unregister 移除 echo 对应的响应通道
+func (rw *ResponseWaiter) unregister(echo string) {
+	rw.mu.Lock()
+	delete(rw.waiters, echo)
+	rw.mu.Unlock()
+}
+
+// deadlineOf 返回从现在开始的一次调用的截止时间，超时时间优先使用 ctx 中的设置（见 WithTimeout），
+// 不限制时返回零值
+func deadlineOf(ctx context.Context) time.Time {
+	timeout, ok := ctx.Value(timeoutKey{}).(time.Duration)
+	if !ok {
+		timeout = time.Duration(defaultTimeout.Load())
+	}
+	if timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(timeout)
+}
+
+// wait 等待响应、到达截止时间 deadline（零值表示不限制）、连接断开或 ctx 取消
+func (rw *ResponseWaiter) wait(ctx context.Context, echo string, respChan chan *APIResponse, deadline time.Time) (*APIResponse, error) {
+	var expired <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		expired = timer.C
+	}
 
-	// 等待响应或超时
 	select {
 	case resp := <-respChan:
 		return resp, nil
-	case <-time.After(rw.timeout):
-		return nil, fmt.Errorf("等待响应超时: %s", echo)
+	case <-expired:
+		return nil, fmt.Errorf("%w: %s", ErrTimeout, echo)
+	case <-rw.closed:
+		// 响应可能与断开同时到达
+		select {
+		case resp := <-respChan:
+			return resp, nil
+		default:
+		}
+		return nil, fmt.Errorf("%w: %s", ErrConnectionClosed, echo)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("等待响应被取消: %s: %w", echo, ctx.Err())
 	}
 }
 
@@ -103,12 +194,129 @@ func (rw *ResponseWaiter) HandleAPIResponse(data map[string]interface{}) {
 	}
 }
 
+// callAPI 发送 API 请求并等待响应
+// 等待会在响应超时、连接断开或 b 绑定的上下文（见 plugin.WithContext）取消时结束，
+// 启用发送队列时发送消息的 API 会先排队（见 ConfigureSendQueue）
+func callAPI(b plugin.Bot, action string, params map[string]interface{}) (*APIResponse, error) {
+	ctx := plugin.ContextOf(b)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// 发送消息的 API 在启用发送队列时排队发送
+	if target, ok := sendTarget(b.GetSelfID(), action, params); ok && outbox.enabled() {
+		return outbox.send(ctx, b, target, action, params)
+	}
+	return call(ctx, b, action, params)
+}
+
+// call 立即发送 API 请求并等待响应或 ctx 取消
+func call(ctx context.Context, b plugin.Bot, action string, params map[string]interface{}) (*APIResponse, error) {
+	echo := generateEcho(action)
+	data := map[string]interface{}{
+		"action": action,
+		"echo":   echo,
+	}
+	if params != nil {
+		data["params"] = params
+	}
+
+	logger := plugin.LoggerOf(ctx).With(logging.KeySelfID, b.GetSelfID(), "action", action, logging.KeyEcho, echo)
+	logger.Debug("调用 API")
+
+	// 先注册再发送，避免响应先于注册到达而丢失
+	waiter := waiterOf(b)
+	respChan := waiter.register(echo)
+	defer waiter.unregister(echo)
+
+	// 超时时间从发送开始计算，同时限制发送（如 HTTP 请求）和等待响应
+	deadline := deadlineOf(ctx)
+	if err := writeBefore(ctx, b, data, deadline); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			err = fmt.Errorf("%w: %s", ErrTimeout, echo)
+		}
+		logger.Warn("发送 API 请求失败", logging.KeyError, err)
+		return nil, err
+	}
+
+	resp, err := waiter.wait(ctx, echo, respChan, deadline)
+	switch {
+	case err != nil:
+		logger.Warn("等待 API 响应失败", logging.KeyError, err)
+	case resp.Status == "failed":
+		logger.Warn("API 调用失败", "retcode", resp.Retcode)
+	}
+	return resp, err
+}
+
+// writeBefore 在截止时间 deadline（零值表示不限制）之前发送请求
+func writeBefore(ctx context.Context, b plugin.Bot, v interface{}, deadline time.Time) error {
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+	return writeJSON(ctx, b, v)
+}
+
+// writeJSON 发送请求，b 或其包装的 Bot 实现了 ContextWriter 时带上 ctx 发送
+func writeJSON(ctx context.Context, b plugin.Bot, v interface{}) error {
+	for inner := b; inner != nil; {
+		if w, ok := inner.(ContextWriter); ok {
+			return w.WriteJSONContext(ctx, v)
+		}
+		unwrapper, ok := inner.(interface{ Unwrap() plugin.Bot })
+		if !ok {
+			break
+		}
+		inner = unwrapper.Unwrap()
+	}
+	return b.WriteJSON(v)
+}
+
+// Responder 拥有自己的响应等待器的 Bot（如每个连接一个等待器的 bot.Bot），
+// 通过它发起的 API 调用只接收该等待器收到的响应，其余的 Bot 使用全局等待器（见 HandleAPIResponse）
+type Responder interface {
+	Responses() *ResponseWaiter
+}
+
+// waiterOf 返回 b 的响应等待器，依次查看被包装的 Bot（见 plugin.WithContext）
+func waiterOf(b plugin.Bot) *ResponseWaiter {
+	for b != nil {
+		if r, ok := b.(Responder); ok {
+			if rw := r.Responses(); rw != nil {
+				return rw
+			}
+		}
+		inner, ok := b.(interface{ Unwrap() plugin.Bot })
+		if !ok {
+			break
+		}
+		b = inner.Unwrap()
+	}
+	return responseWaiter
+}
+
+type timeoutKey struct{}
+
+// WithTimeout 返回 API 调用超时时间为 d 的 Bot（包括发送请求和等待响应），只影响通过它发起的调用，d <= 0 表示不限制
+// （仍然会在连接断开或上下文取消时结束）
+//
+//	info, err := api.GetGroupInfo(api.WithTimeout(bot, 5*time.Second), groupID)
+func WithTimeout(b plugin.Bot, d time.Duration) plugin.Bot {
+	return plugin.WithContext(b, context.WithValue(plugin.ContextOf(b), timeoutKey{}, d))
+}
+
+// 进程内递增的请求序号，保证 echo 不重复
+var echoSeq atomic.Uint64
+
 // 生成唯一 echo
 func generateEcho(action string) string {
-	return fmt.Sprintf("%s_%d", action, time.Now().UnixNano())
+	return fmt.Sprintf("%s#%d", action, echoSeq.Add(1))
 }
 
-// HandleAPIResponse 公共的 API 响应处理函数
+// HandleAPIResponse 公共的 API 响应处理函数，把响应交给全局等待器，
+// 用于没有实现 Responder 的 Bot
 func HandleAPIResponse(data map[string]interface{}) {
 	responseWaiter.HandleAPIResponse(data)
 }
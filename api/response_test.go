@@ -0,0 +1,154 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iamlibie/milonra-go/api"
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+// echoBot answers every request synchronously from inside WriteJSON
+type echoBot struct{}
+
+func (echoBot) WriteJSON(v interface{}) error {
+	data := v.(map[string]interface{})
+	api.HandleAPIResponse(map[string]interface{}{
+		"status":  "ok",
+		"retcode": float64(0),
+		"data":    map[string]interface{}{"user_id": float64(10001), "nickname": "milonra"},
+		"echo":    data["echo"],
+	})
+	return nil
+}
+
+func (echoBot) GetSelfID() int64 { return 10001 }
+
+// silentBot never answers
+type silentBot struct{}
+
+func (silentBot) WriteJSON(v interface{}) error { return nil }
+func (silentBot) GetSelfID() int64              { return 10001 }
+
+func TestResponseBeforeWait(t *testing.T) {
+	info, err := api.GetLoginInfo(echoBot{})
+	if err != nil {
+		t.Fatalf("GetLoginInfo failed: %v", err)
+	}
+	if info.UserID != 10001 || info.Nickname != "milonra" {
+		t.Errorf("Unexpected login info: %+v", info)
+	}
+}
+
+func TestCallCancelledByContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := api.GetLoginInfo(plugin.WithContext(silentBot{}, ctx))
+	if err == nil {
+		t.Fatal("Expected an error when the context expires")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Call was not aborted promptly: %v", time.Since(start))
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	start := time.Now()
+	_, err := api.GetLoginInfo(api.WithTimeout(silentBot{}, 50*time.Millisecond))
+	if !errors.Is(err, api.ErrTimeout) {
+		t.Fatalf("Expected api.ErrTimeout, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Per-call timeout was not applied: %v", time.Since(start))
+	}
+}
+
+// connBot owns its response table like a connection does, and records the echoes it sends
+type connBot struct {
+	responses *api.ResponseWaiter
+	mu        sync.Mutex
+	echoes    []string
+}
+
+func (b *connBot) WriteJSON(v interface{}) error {
+	echo := v.(map[string]interface{})["echo"].(string)
+	b.mu.Lock()
+	b.echoes = append(b.echoes, echo)
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *connBot) GetSelfID() int64               { return 10001 }
+func (b *connBot) Responses() *api.ResponseWaiter { return b.responses }
+
+func TestConnectionResponses(t *testing.T) {
+	b := &connBot{responses: api.NewResponseWaiter()}
+
+	const calls = 50
+	errs := make(chan error, calls)
+	for i := 0; i < calls; i++ {
+		go func() {
+			_, err := api.GetLoginInfo(api.WithTimeout(b, 5*time.Second))
+			errs <- err
+		}()
+	}
+	sent := func() int {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return len(b.echoes)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for sent() < calls {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d requests, got %d", calls, sent())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := b.responses.Pending(); n != calls {
+		t.Errorf("Expected %d pending calls, got %d", calls, n)
+	}
+
+	// echo 不重复
+	b.mu.Lock()
+	seen := make(map[string]bool)
+	for _, echo := range b.echoes {
+		if seen[echo] {
+			t.Errorf("Duplicate echo %q", echo)
+		}
+		seen[echo] = true
+	}
+	echo := b.echoes[0]
+	b.mu.Unlock()
+
+	// 其他连接（全局等待器）收到的响应不会交给该连接的调用
+	api.HandleAPIResponse(map[string]interface{}{"status": "ok", "retcode": float64(0), "echo": echo})
+	select {
+	case err := <-errs:
+		t.Fatalf("Call completed by a response from another connection: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// 连接断开后所有等待中的调用立即失败
+	b.responses.Close()
+	for i := 0; i < calls; i++ {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, api.ErrConnectionClosed) {
+				t.Errorf("Expected api.ErrConnectionClosed, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Pending calls were not failed on close")
+		}
+	}
+	if _, err := api.GetLoginInfo(b); !errors.Is(err, api.ErrConnectionClosed) {
+		t.Errorf("Expected calls after close to fail, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/iamlibie/milonra-go/plugin"
@@ -9,23 +10,11 @@ import (
 
 // SetFriendAddRequest 处理加好友请求
 func SetFriendAddRequest(b plugin.Bot, flag string, approve bool, remark string) error {
-	echo := generateEcho("set_friend_add_request")
-	data := map[string]interface{}{
-		"action": "set_friend_add_request",
-		"params": map[string]interface{}{
-			"flag":    flag,
-			"approve": approve,
-			"remark":  remark,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "set_friend_add_request", map[string]interface{}{
+		"flag":    flag,
+		"approve": approve,
+		"remark":  remark,
+	})
 	if err != nil {
 		return err
 	}
@@ -39,24 +28,12 @@ func SetFriendAddRequest(b plugin.Bot, flag string, approve bool, remark string)
 
 // SetGroupAddRequest 处理加群请求/邀请
 func SetGroupAddRequest(b plugin.Bot, flag, subType string, approve bool, reason string) error {
-	echo := generateEcho("set_group_add_request")
-	data := map[string]interface{}{
-		"action": "set_group_add_request",
-		"params": map[string]interface{}{
-			"flag":     flag,
-			"sub_type": subType,
-			"approve":  approve,
-			"reason":   reason,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "set_group_add_request", map[string]interface{}{
+		"flag":     flag,
+		"sub_type": subType,
+		"approve":  approve,
+		"reason":   reason,
+	})
 	if err != nil {
 		return err
 	}
@@ -70,18 +47,7 @@ func SetGroupAddRequest(b plugin.Bot, flag, subType string, approve bool, reason
 
 // GetLoginInfo 获取登录号信息
 func GetLoginInfo(b plugin.Bot) (*LoginInfo, error) {
-	echo := generateEcho("get_login_info")
-	data := map[string]interface{}{
-		"action": "get_login_info",
-		"echo":   echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_login_info", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -101,18 +67,7 @@ func GetLoginInfo(b plugin.Bot) (*LoginInfo, error) {
 
 // GetFriendList 获取好友列表
 func GetFriendList(b plugin.Bot) ([]FriendInfo, error) {
-	echo := generateEcho("get_friend_list")
-	data := map[string]interface{}{
-		"action": "get_friend_list",
-		"echo":   echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_friend_list", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -132,21 +87,9 @@ func GetFriendList(b plugin.Bot) ([]FriendInfo, error) {
 
 // GetCookies 获取Cookies
 func GetCookies(b plugin.Bot, domain string) (*Cookies, error) {
-	echo := generateEcho("get_cookies")
-	data := map[string]interface{}{
-		"action": "get_cookies",
-		"params": map[string]interface{}{
-			"domain": domain,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_cookies", map[string]interface{}{
+		"domain": domain,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -166,18 +109,7 @@ func GetCookies(b plugin.Bot, domain string) (*Cookies, error) {
 
 // GetCSRFToken 获取CSRF Token
 func GetCSRFToken(b plugin.Bot) (*CSRFToken, error) {
-	echo := generateEcho("get_csrf_token")
-	data := map[string]interface{}{
-		"action": "get_csrf_token",
-		"echo":   echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_csrf_token", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -197,21 +129,9 @@ func GetCSRFToken(b plugin.Bot) (*CSRFToken, error) {
 
 // GetCredentials 获取QQ相关接口凭证
 func GetCredentials(b plugin.Bot, domain string) (*Credentials, error) {
-	echo := generateEcho("get_credentials")
-	data := map[string]interface{}{
-		"action": "get_credentials",
-		"params": map[string]interface{}{
-			"domain": domain,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_credentials", map[string]interface{}{
+		"domain": domain,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -231,22 +151,10 @@ func GetCredentials(b plugin.Bot, domain string) (*Credentials, error) {
 
 // GetRecord 获取语音
 func GetRecord(b plugin.Bot, file, outFormat string) (*RecordInfo, error) {
-	echo := generateEcho("get_record")
-	data := map[string]interface{}{
-		"action": "get_record",
-		"params": map[string]interface{}{
-			"file":       file,
-			"out_format": outFormat,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_record", map[string]interface{}{
+		"file":       file,
+		"out_format": outFormat,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -266,21 +174,9 @@ func GetRecord(b plugin.Bot, file, outFormat string) (*RecordInfo, error) {
 
 // GetImage 获取图片
 func GetImage(b plugin.Bot, file string) (*ImageInfo, error) {
-	echo := generateEcho("get_image")
-	data := map[string]interface{}{
-		"action": "get_image",
-		"params": map[string]interface{}{
-			"file": file,
-		},
-		"echo": echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_image", map[string]interface{}{
+		"file": file,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -300,18 +196,7 @@ func GetImage(b plugin.Bot, file string) (*ImageInfo, error) {
 
 // CanSendImage 检查是否可以发送图片
 func CanSendImage(b plugin.Bot) (bool, error) {
-	echo := generateEcho("can_send_image")
-	data := map[string]interface{}{
-		"action": "can_send_image",
-		"echo":   echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return false, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "can_send_image", nil)
 	if err != nil {
 		return false, err
 	}
@@ -331,18 +216,7 @@ func CanSendImage(b plugin.Bot) (bool, error) {
 
 // CanSendRecord 检查是否可以发送语音
 func CanSendRecord(b plugin.Bot) (bool, error) {
-	echo := generateEcho("can_send_record")
-	data := map[string]interface{}{
-		"action": "can_send_record",
-		"echo":   echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return false, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "can_send_record", nil)
 	if err != nil {
 		return false, err
 	}
@@ -362,18 +236,7 @@ func CanSendRecord(b plugin.Bot) (bool, error) {
 
 // GetStatus 获取运行状态
 func GetStatus(b plugin.Bot) (*Status, error) {
-	echo := generateEcho("get_status")
-	data := map[string]interface{}{
-		"action": "get_status",
-		"echo":   echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_status", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -393,18 +256,7 @@ func GetStatus(b plugin.Bot) (*Status, error) {
 
 // GetVersionInfo 获取版本信息
 func GetVersionInfo(b plugin.Bot) (*VersionInfo, error) {
-	echo := generateEcho("get_version_info")
-	data := map[string]interface{}{
-		"action": "get_version_info",
-		"echo":   echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "get_version_info", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -424,28 +276,18 @@ func GetVersionInfo(b plugin.Bot) (*VersionInfo, error) {
 
 // SetRestart 重启OneBot实现
 func SetRestart(b plugin.Bot, delay int) error {
-	echo := generateEcho("set_restart")
-	data := map[string]interface{}{
-		"action": "set_restart",
-		"params": map[string]interface{}{
-			"delay": delay,
-		},
-		"echo": echo,
+	// 重启是异步操作，可能不会收到响应
+	resp, err := callAPI(b, "set_restart", map[string]interface{}{
+		"delay": delay,
+	})
+	if errors.Is(err, ErrTimeout) {
+		// 超时可能是正常的（因为服务正在重启）
+		return nil
 	}
-
-	err := b.WriteJSON(data)
 	if err != nil {
 		return err
 	}
 
-	// 重启是异步操作，可能不会收到响应
-	// 设置较短的超时时间
-	resp, err := responseWaiter.WaitForResponse(echo)
-	if err != nil {
-		// 如果是超时错误，可能是正常的（因为服务正在重启）
-		return nil
-	}
-
 	if resp.Status != "ok" && resp.Status != "async" {
 		return fmt.Errorf("重启失败: %s", resp.Status)
 	}
@@ -455,18 +297,7 @@ func SetRestart(b plugin.Bot, delay int) error {
 
 // CleanCache 清理缓存
 func CleanCache(b plugin.Bot) error {
-	echo := generateEcho("clean_cache")
-	data := map[string]interface{}{
-		"action": "clean_cache",
-		"echo":   echo,
-	}
-
-	err := b.WriteJSON(data)
-	if err != nil {
-		return err
-	}
-
-	resp, err := responseWaiter.WaitForResponse(echo)
+	resp, err := callAPI(b, "clean_cache", nil)
 	if err != nil {
 		return err
 	}
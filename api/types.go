@@ -1,26 +1,16 @@
 package api
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/iamlibie/milonra-go/event"
+)
 
 // Sender 发送者信息
-type Sender struct {
-	UserID   int64  `json:"user_id"`
-	Nickname string `json:"nickname"`
-	Sex      string `json:"sex,omitempty"`
-	Age      int64  `json:"age,omitempty"`
-	Card     string `json:"card,omitempty"`  // 群名片
-	Area     string `json:"area,omitempty"`  // 地区
-	Level    string `json:"level,omitempty"` // 成员等级
-	Role     string `json:"role,omitempty"`  // 角色 owner/admin/member
-	Title    string `json:"title,omitempty"` // 专属头衔
-}
+type Sender = event.Sender
 
 // Anonymous 匿名信息
-type Anonymous struct {
-	ID   int64  `json:"id"`
-	Name string `json:"name"`
-	Flag string `json:"flag"`
-}
+type Anonymous = event.Anonymous
 
 // File 文件信息
 type File struct {
@@ -1,50 +1,146 @@
 package bot
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 
-	"log"
-
 	"github.com/iamlibie/milonra-go/api"
 	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/filter"
+	"github.com/iamlibie/milonra-go/logging"
 	"github.com/iamlibie/milonra-go/plugin"
+	"github.com/iamlibie/milonra-go/session"
 )
 
 type Bot struct {
-	Conn       *websocket.Conn
-	SelfID     int64
-	writeMutex sync.Mutex
+	Conn          *websocket.Conn
+	Transport     Transport // 发送 API 请求的方式（可选），设置后代替 Conn，如 api.HTTPTransport
+	SelfID        int64
+	OnMeta        func(e event.Meta) // 收到元事件时同步调用（可选），用于维护连接状态
+	Ctx           context.Context    // 连接的上下文（可选），连接断开或服务停止时取消，插件的上下文由它派生
+	PluginTimeout time.Duration      // 单次插件调用的超时时间，0 表示不限制，可被 plugin.SetTimeout 覆盖
+	writeMutex    sync.Mutex
+	selfMu        sync.RWMutex
+
+	responsesOnce sync.Once
+	responses     *api.ResponseWaiter
+}
+
+// Transport 发送 OneBot API 请求的方式，实现需要可以被并发调用，
+// 并在收到响应后交给等待者：实现了 api.Responder 时交给自己的等待器，否则通过 api.HandleAPIResponse
+type Transport interface {
+	WriteJSON(v interface{}) error
+}
+
+// Responses 返回该连接的响应等待器（实现 api.Responder），通过该 Bot 发起的 API 调用只接收该连接上的响应；
+// 设置了实现 api.Responder 的 Transport 时返回 Transport 的等待器
+func (b *Bot) Responses() *api.ResponseWaiter {
+	if r, ok := b.Transport.(api.Responder); ok {
+		return r.Responses()
+	}
+	b.responsesOnce.Do(func() {
+		b.responses = api.NewResponseWaiter()
+	})
+	return b.responses
+}
+
+// Close 连接断开后调用，使等待该连接响应的 API 调用立即以 api.ErrConnectionClosed 失败，
+// 并关闭 WebSocket 连接（如果有）
+func (b *Bot) Close() error {
+	b.Responses().Close()
+	if b.Conn != nil {
+		return b.Conn.Close()
+	}
+	return nil
 }
 
-// WriteJSON 线程安全地向WebSocket写入JSON数据
+// WriteJSON 线程安全地向WebSocket写入JSON数据，设置了 Transport 时交给 Transport 发送
 func (b *Bot) WriteJSON(v interface{}) error {
+	if b.Transport != nil {
+		return b.Transport.WriteJSON(v)
+	}
 	b.writeMutex.Lock()
 	defer b.writeMutex.Unlock()
+	if b.Conn == nil {
+		return errors.New("WebSocket 未连接")
+	}
 	return b.Conn.WriteJSON(v)
 }
 
+// WriteJSONContext 实现 api.ContextWriter，Transport 实现了 api.ContextWriter 时带上 ctx 发送，
+// 否则与 WriteJSON 相同
+func (b *Bot) WriteJSONContext(ctx context.Context, v interface{}) error {
+	if w, ok := b.Transport.(api.ContextWriter); ok {
+		return w.WriteJSONContext(ctx, v)
+	}
+	return b.WriteJSON(v)
+}
+
 // GetSelfID 实现api.BotAPI接口
 func (b *Bot) GetSelfID() int64 {
+	b.selfMu.RLock()
+	defer b.selfMu.RUnlock()
 	return b.SelfID
 }
 
+// SetSelfID 设置机器人ID，用于连接建立后才从上报中得知账号的情况，可以与 GetSelfID 并发调用
+func (b *Bot) SetSelfID(selfID int64) {
+	b.selfMu.Lock()
+	defer b.selfMu.Unlock()
+	b.SelfID = selfID
+}
+
+// Context 返回连接的上下文，未设置时返回 context.Background()
+func (b *Bot) Context() context.Context {
+	if b.Ctx == nil {
+		return context.Background()
+	}
+	return b.Ctx
+}
+
+// pluginContext 为插件处理 trigger 事件创建上下文，超时时间优先使用插件单独的设置，
+// 上下文中带有该插件和事件的日志记录器（见 plugin.LoggerOf）
+func (b *Bot) pluginContext(name string, trigger interface{}) (context.Context, context.CancelFunc) {
+	ctx := plugin.WithLogger(b.Context(), eventLogger(trigger).With(logging.KeyPlugin, name))
+	timeout, ok := plugin.GetTimeout(name)
+	if !ok {
+		timeout = b.PluginTimeout
+	}
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
 // SendMessage 发送群消息（封装 OneBot API）
 
 // HandleMessage 处理收到的消息
 func (b *Bot) HandleMessage(data map[string]interface{}) {
 	// 首先检查是否为 API 响应
-	api.HandleAPIResponse(data)
-
-	// 检查是否为消息事件
-	postType, ok := data["post_type"].(string)
-	if !ok || postType != "message" {
-		// 可能是 heartbeat、meta_event 或 API 响应等，直接返回
-		return
+	b.Responses().HandleAPIResponse(data)
+
+	// 按上报类型分发
+	postType, _ := data["post_type"].(string)
+	switch postType {
+	case "message":
+		b.handleMessageEvent(data)
+	case "notice":
+		b.handleNoticeEvent(data)
+	case "request":
+		b.handleRequestEvent(data)
+	case "meta_event":
+		b.handleMetaEvent(data)
+	default:
+		// API 响应等，直接返回
 	}
+}
 
+// handleMessageEvent 处理消息事件
+func (b *Bot) handleMessageEvent(data map[string]interface{}) {
 	// 检查消息类型（群聊或私聊）
 	messageType, ok := data["message_type"].(string)
 	if !ok || (messageType != "group" && messageType != "private") {
@@ -55,65 +151,61 @@ func (b *Bot) HandleMessage(data map[string]interface{}) {
 	// 提取用户ID（群聊和私聊都有）
 	userID, ok := data["user_id"].(float64)
 	if !ok {
-		log.Printf("❌ user_id 不存在或不是 float64: %v", data["user_id"])
+		logging.Logger().Error("消息缺少 user_id 或类型错误", logging.KeySelfID, b.GetSelfID(), logging.KeyUserID, data["user_id"])
 		return
 	}
 
 	// 提取时间戳
-	time, ok := data["time"].(float64)
+	timestamp, ok := data["time"].(float64)
 	if !ok {
-		log.Printf("❌ time 不存在或不是 float64: %v", data["time"])
+		logging.Logger().Error("消息缺少 time 或类型错误", logging.KeySelfID, b.GetSelfID(), "time", data["time"])
 		return
 	}
 
 	// 创建消息事件
 	msgEvent := &event.MessageEvent{
-		UserID:     int64(userID),
-		Message:    api.ExtractMessage(data),
-		RawMessage: api.GetString(data, "raw_message"),
-		Time:       int64(time),
-		RawData:    data,
+		MessageID:   int32(api.GetInt64(data, "message_id")),
+		MessageType: messageType,
+		SubType:     api.GetString(data, "sub_type"),
+		SelfID:      api.GetInt64(data, "self_id"),
+		UserID:      int64(userID),
+		Message:     api.ExtractMessage(data),
+		RawMessage:  api.GetString(data, "raw_message"),
+		Segments:    parseSegments(data),
+		Time:        int64(timestamp),
+		RawData:     data,
 	}
+	if msgEvent.SelfID == 0 {
+		msgEvent.SelfID = b.GetSelfID()
+	}
+	parseSender(data, msgEvent)
 
 	// 如果是群聊消息，设置群ID
 	if messageType == "group" {
 		groupID, ok := data["group_id"].(float64)
 		if !ok {
-			log.Printf("❌ group_id 不存在或不是 float64: %v", data["group_id"])
+			eventLogger(msgEvent).Error("群消息缺少 group_id 或类型错误", logging.KeyGroupID, data["group_id"])
 			return
 		}
 		msgEvent.GroupID = int64(groupID)
-		fmt.Printf("[群:%d] 用户:%d 说: %s\n", msgEvent.GroupID, msgEvent.UserID, msgEvent.Message)
-	} else {
-		// 私聊消息
-		fmt.Printf("[私聊] 用户:%d 说: %s\n", msgEvent.UserID, msgEvent.Message)
 	}
+	eventLogger(msgEvent).Info("收到消息", "message_type", messageType, "message", msgEvent.Message)
 
 	// 检查是否@了机器人
-	if api.IsAtMe(msgEvent.Message, b.SelfID) {
+	if api.IsAtMe(msgEvent.Message, msgEvent.SelfID) || isAtSelf(msgEvent.Segments, msgEvent.SelfID) {
 		msgEvent.IsAtMe = true
 	}
 
-	// 调用各个插件处理
-	for name, pluginFunc := range plugin.GetPlugins() {
-		// 异步发送回复，避免阻塞
-		go func(evt *event.MessageEvent, pf plugin.PluginFunc, name string) {
-			reply := pf(b, evt)
-			if reply == "" {
-				return
-			}
-			log.Printf("插件%s被调用", name)
-			var err error
-			if evt.GroupID != 0 {
-				// 群聊消息
-				_, err = api.SendGroupMessage(b, evt.GroupID, reply)
-			} else {
-				// 私聊消息
-				_, err = api.SendPrivateMessage(b, evt.UserID, reply)
-			}
-			if err != nil {
-				log.Printf("❌ 发送消息失败: %v", err)
-			}
-		}(msgEvent, pluginFunc, name)
+	// 黑白名单过滤
+	if !filter.Allowed(msgEvent.GroupID, msgEvent.UserID) {
+		return
+	}
+
+	// 有插件正在等待该用户回复时，消息只交给该会话
+	if session.Deliver(msgEvent) {
+		return
 	}
+
+	// 按优先级调用各个插件处理，异步执行避免阻塞读取
+	go b.dispatch(msgEvent)
 }
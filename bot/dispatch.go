@@ -0,0 +1,159 @@
+package bot
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/plugin"
+	"github.com/iamlibie/milonra-go/ratelimit"
+	"github.com/iamlibie/milonra-go/session"
+)
+
+// dispatch 按优先级从高到低将消息分发给各个插件
+//
+// 同一优先级的插件并发执行；如果该优先级中有设置了 Block 的插件，
+// 会等待这些插件执行完毕，任意一个处理了消息（返回非 nil 的回复）就不再分发给更低优先级的插件
+func (b *Bot) dispatch(msgEvent *event.MessageEvent) {
+	quota := newMessageQuota(msgEvent)
+	var all sync.WaitGroup
+	defer func() {
+		all.Wait()
+		quota.release(msgEvent)
+	}()
+
+	for _, tier := range plugin.Tiers() {
+		var wg sync.WaitGroup
+		var blocked atomic.Bool
+
+		for _, p := range tier {
+			all.Add(1)
+			if p.Block {
+				wg.Add(1)
+			}
+			go func() {
+				defer all.Done()
+				if p.Block {
+					defer wg.Done()
+				}
+				safeCall(p.Name, msgEvent, func() bool {
+					ran, handled := b.runPlugin(p, msgEvent, quota)
+					if handled && p.Block {
+						blocked.Store(true)
+					}
+					return ran
+				})
+			}()
+		}
+
+		wg.Wait()
+		if blocked.Load() {
+			return
+		}
+	}
+}
+
+// messageQuota 一条消息的全局限流：每条消息只消耗一次全局限流（而不是每个插件一次），
+// 分发结束后没有任何插件处理该消息时归还，超级用户不受限制
+type messageQuota struct {
+	limited    bool          // 发送者受全局限流约束
+	allowed    bool          // 全局限流是否放行了该消息
+	retryAfter time.Duration // 被限流时需要等待的时间
+	used       atomic.Bool   // 是否有插件处理了该消息
+}
+
+// newMessageQuota 在分发前消耗消息发送者和所在群的一次全局限流
+func newMessageQuota(msgEvent *event.MessageEvent) *messageQuota {
+	q := &messageQuota{allowed: true}
+	if plugin.IsSuperuser(msgEvent.UserID) {
+		return q
+	}
+	q.limited = true
+	q.retryAfter, q.allowed = ratelimit.Allow(msgEvent.GroupID, msgEvent.UserID)
+	return q
+}
+
+// release 没有插件处理该消息时归还消耗的全局限流
+func (q *messageQuota) release(msgEvent *event.MessageEvent) {
+	if q.limited && q.allowed && !q.used.Load() {
+		ratelimit.Refund(msgEvent.GroupID, msgEvent.UserID)
+	}
+}
+
+// runPlugin 检查插件开关、匹配规则、权限和限流后执行一个消息插件并发送回复
+// ran 表示插件的处理函数是否实际执行了，handled 表示插件是否处理了该消息（包括权限不足和被限流的提示）
+func (b *Bot) runPlugin(p *plugin.Plugin, msgEvent *event.MessageEvent, quota *messageQuota) (ran, handled bool) {
+	if !plugin.IsEnabled(p.Name, msgEvent.GroupID, msgEvent.UserID) {
+		return false, false
+	}
+	match, ok := p.Match(msgEvent)
+	if !ok {
+		return false, false
+	}
+
+	ctx, cancel := b.pluginContext(p.Name, msgEvent)
+	defer cancel()
+
+	if !p.Authorized(msgEvent) {
+		// 只有声明了匹配规则的插件才提示权限不足，避免对每条消息都回复
+		if len(p.Rules) == 0 {
+			return false, false
+		}
+		b.sendReply(ctx, msgEvent, plugin.DeniedReply(p.Permission, p.Roles))
+		return false, true
+	}
+	ctx = plugin.WithMatch(ctx, match)
+	ctx = session.NewContext(ctx, b, msgEvent)
+
+	// 限流：全局限流在分发前按消息消耗，插件自身的限流在执行前预留，避免并发的消息同时通过检查；
+	// 插件没有处理消息时归还，超级用户不受限制
+	if quota.limited {
+		if retryAfter, ok := reserveRateLimit(p, msgEvent, quota); !ok {
+			// 没有匹配规则的插件对每条消息都会执行，被限流时不提示
+			if len(p.Rules) == 0 {
+				return false, false
+			}
+			if msg := ratelimit.Reject(msgEvent.UserID, retryAfter); msg != "" {
+				b.sendReply(ctx, msgEvent, plugin.NewReply(msg).Quoted())
+			}
+			return false, true
+		}
+	}
+
+	reply := p.Handler(ctx, plugin.WithContext(b, ctx), msgEvent)
+	if reply == nil {
+		if quota.limited {
+			refundRateLimit(p, msgEvent)
+		}
+		return true, false
+	}
+	quota.used.Store(true)
+	plugin.LoggerOf(ctx).Debug("插件处理了消息")
+	b.sendReply(ctx, msgEvent, reply)
+	return true, true
+}
+
+// reserveRateLimit 检查消息是否通过了全局限流，并消耗插件自身限流的一次触发
+func reserveRateLimit(p *plugin.Plugin, msgEvent *event.MessageEvent, quota *messageQuota) (time.Duration, bool) {
+	if p.RateLimit == nil {
+		return quota.retryAfter, quota.allowed
+	}
+	key := strconv.FormatInt(msgEvent.UserID, 10)
+	if !quota.allowed {
+		retryAfter := quota.retryAfter
+		if wait, allowed := p.RateLimit.Check(key); !allowed {
+			retryAfter = max(retryAfter, wait)
+		}
+		return retryAfter, false
+	}
+	return p.RateLimit.Allow(key)
+}
+
+// refundRateLimit 归还 reserveRateLimit 消耗的插件自身限流的次数
+func refundRateLimit(p *plugin.Plugin, msgEvent *event.MessageEvent) {
+	if p.RateLimit != nil {
+		p.RateLimit.Refund(strconv.FormatInt(msgEvent.UserID, 10))
+	}
+}
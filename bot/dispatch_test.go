@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/plugin"
+	"github.com/iamlibie/milonra-go/ratelimit"
+)
+
+func TestDispatchRateLimitPerMessage(t *testing.T) {
+	ratelimit.Configure(ratelimit.Config{Enabled: true, RequestsPerMinute: 2})
+	t.Cleanup(func() { ratelimit.Configure(ratelimit.Config{}) })
+
+	// 同一优先级的多个插件共享一条消息的全局限流，不处理消息的插件不消耗限流
+	skip := func(bot plugin.Bot, e *event.MessageEvent) *plugin.Reply { return nil }
+	echo := func(bot plugin.Bot, e *event.MessageEvent) *plugin.Reply { return plugin.NewReply(e.RawMessage) }
+	plugin.RegisterReply("dispatch_test_skip", skip)
+	plugin.RegisterReply("dispatch_test_echo_a", echo)
+	plugin.RegisterReply("dispatch_test_echo_b", echo)
+	t.Cleanup(func() {
+		plugin.Unregister("dispatch_test_skip")
+		plugin.Unregister("dispatch_test_echo_a")
+		plugin.Unregister("dispatch_test_echo_b")
+	})
+
+	b, transport := newRecordingBot()
+	for i := 0; i < 2; i++ {
+		b.dispatch(&event.MessageEvent{MessageType: "private", UserID: 111222333, RawMessage: "hi"})
+	}
+	if len(transport.calls) != 4 {
+		t.Fatalf("Expected both plugins to reply to both messages, got %d replies", len(transport.calls))
+	}
+
+	// 额度用完后，没有匹配规则的插件被限流时不回复
+	b.dispatch(&event.MessageEvent{MessageType: "private", UserID: 111222333, RawMessage: "hi"})
+	if len(transport.calls) != 4 {
+		t.Errorf("Expected the third message to be limited, got %d replies", len(transport.calls))
+	}
+
+	// 没有插件处理的消息归还限流
+	plugin.Unregister("dispatch_test_echo_a")
+	plugin.Unregister("dispatch_test_echo_b")
+	for i := 0; i < 3; i++ {
+		b.dispatch(&event.MessageEvent{MessageType: "private", UserID: 444555666, RawMessage: "hi"})
+	}
+	if _, ok := ratelimit.Allow(0, 444555666); !ok {
+		t.Error("Expected unhandled messages not to consume the rate limit")
+	}
+}
@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"strconv"
+
+	"github.com/iamlibie/milonra-go/api"
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/logging"
+)
+
+// parseSegments 解析消息段，兼容数组格式和 CQ 码字符串格式的上报
+func parseSegments(data map[string]interface{}) []api.MessageSegment {
+	switch msg := data["message"].(type) {
+	case string:
+		return api.ParseCQCode(msg).Build()
+	case []interface{}:
+		segments := make([]api.MessageSegment, 0, len(msg))
+		for _, seg := range msg {
+			segMap, ok := seg.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			segment := api.MessageSegment{
+				Type: api.GetString(segMap, "type"),
+				Data: map[string]interface{}{},
+			}
+			if segData, ok := segMap["data"].(map[string]interface{}); ok {
+				segment.Data = segData
+			}
+			segments = append(segments, segment)
+		}
+		return segments
+	}
+	return nil
+}
+
+// parseSender 解析发送者和匿名信息，并填充昵称
+func parseSender(data map[string]interface{}, e *event.MessageEvent) {
+	if sender, ok := data["sender"].(map[string]interface{}); ok {
+		if err := decodeEvent(sender, &e.Sender); err != nil {
+			eventLogger(e).Warn("解析发送者信息失败", logging.KeyError, err)
+		}
+		e.Nickname = e.Sender.Nickname
+	}
+
+	if anonymous, ok := data["anonymous"].(map[string]interface{}); ok {
+		e.Anonymous = &event.Anonymous{}
+		if err := decodeEvent(anonymous, e.Anonymous); err != nil {
+			eventLogger(e).Warn("解析匿名信息失败", logging.KeyError, err)
+		}
+		e.Nickname = e.Anonymous.Name
+	}
+}
+
+// isAtSelf 检查消息段中是否 @ 了机器人
+func isAtSelf(segments []api.MessageSegment, selfID int64) bool {
+	self := strconv.FormatInt(selfID, 10)
+	for _, seg := range segments {
+		if seg.Type != "at" {
+			continue
+		}
+		switch qq := seg.Data["qq"].(type) {
+		case string:
+			if qq == self {
+				return true
+			}
+		case float64:
+			if int64(qq) == selfID {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package bot
+
+import (
+	"github.com/iamlibie/milonra-go/api"
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/logging"
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+// handleMetaEvent 处理元事件
+func (b *Bot) handleMetaEvent(data map[string]interface{}) {
+	meta, err := parseMeta(data)
+	if err != nil {
+		logging.Logger().Error("解析元事件失败", logging.KeySelfID, b.GetSelfID(), logging.KeyError, err)
+		return
+	}
+
+	if lifecycle, ok := meta.(*event.LifecycleEvent); ok {
+		eventLogger(meta).Info("生命周期事件", "sub_type", lifecycle.SubType)
+	}
+
+	// 先同步通知连接的持有者（如 SDK 的心跳监控），再分发给插件
+	if b.OnMeta != nil {
+		b.OnMeta(meta)
+	}
+
+	// 元事件不属于任何群或用户，只受全局开关控制
+	for name, metaFunc := range plugin.GetMetaPlugins() {
+		if !plugin.IsEnabled(name, 0, 0) {
+			continue
+		}
+		go safeCall(name, meta, func() bool {
+			ctx, cancel := b.pluginContext(name, meta)
+			defer cancel()
+			metaFunc(plugin.WithContext(b, ctx), meta)
+			return true
+		})
+	}
+}
+
+// parseMeta 将 OneBot 元事件上报解析为对应的元事件类型
+func parseMeta(data map[string]interface{}) (event.Meta, error) {
+	var meta event.Meta
+	switch api.GetString(data, "meta_event_type") {
+	case event.MetaLifecycle:
+		meta = &event.LifecycleEvent{}
+	case event.MetaHeartbeat:
+		meta = &event.HeartbeatEvent{}
+	default:
+		meta = &event.MetaEvent{}
+	}
+
+	if err := decodeEvent(data, meta); err != nil {
+		return nil, err
+	}
+	meta.Base().RawData = data
+	return meta, nil
+}
@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"encoding/json"
+
+	"github.com/iamlibie/milonra-go/api"
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/filter"
+	"github.com/iamlibie/milonra-go/logging"
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+// handleNoticeEvent 处理通知事件
+func (b *Bot) handleNoticeEvent(data map[string]interface{}) {
+	notice, err := parseNotice(data)
+	if err != nil {
+		logging.Logger().Error("解析通知事件失败", logging.KeySelfID, b.GetSelfID(), logging.KeyError, err)
+		return
+	}
+
+	base := notice.Base()
+	if !filter.Allowed(base.GroupID, base.UserID) {
+		return
+	}
+	eventLogger(notice).Info("收到通知", "notice_type", base.NoticeType, "sub_type", base.SubType)
+
+	// 调用各个通知插件处理
+	for name, noticeFunc := range plugin.GetNoticePlugins() {
+		if !plugin.IsEnabled(name, base.GroupID, base.UserID) {
+			continue
+		}
+		go safeCall(name, notice, func() bool {
+			ctx, cancel := b.pluginContext(name, notice)
+			defer cancel()
+			reply := noticeFunc(plugin.WithContext(b, ctx), notice)
+			if reply == "" {
+				return true
+			}
+			logger := plugin.LoggerOf(ctx)
+			logger.Debug("通知插件处理了通知")
+			if err := b.send(ctx, base.GroupID, base.UserID, reply); err != nil {
+				logger.Error("发送消息失败", logging.KeyError, err)
+			}
+			return true
+		})
+	}
+}
+
+// parseNotice 将 OneBot 通知上报解析为对应的通知事件类型
+func parseNotice(data map[string]interface{}) (event.Notice, error) {
+	var notice event.Notice
+	switch api.GetString(data, "notice_type") {
+	case event.NoticeGroupUpload:
+		notice = &event.GroupUploadNotice{}
+	case event.NoticeGroupAdmin:
+		notice = &event.GroupAdminNotice{}
+	case event.NoticeGroupDecrease:
+		notice = &event.GroupDecreaseNotice{}
+	case event.NoticeGroupIncrease:
+		notice = &event.GroupIncreaseNotice{}
+	case event.NoticeGroupBan:
+		notice = &event.GroupBanNotice{}
+	case event.NoticeFriendAdd:
+		notice = &event.FriendAddNotice{}
+	case event.NoticeGroupRecall:
+		notice = &event.GroupRecallNotice{}
+	case event.NoticeFriendRecall:
+		notice = &event.FriendRecallNotice{}
+	case event.NoticeGroupCard:
+		notice = &event.GroupCardNotice{}
+	case event.NoticeNotify:
+		switch api.GetString(data, "sub_type") {
+		case "poke":
+			notice = &event.PokeNotice{}
+		case "lucky_king":
+			notice = &event.LuckyKingNotice{}
+		case "honor":
+			notice = &event.HonorNotice{}
+		default:
+			notice = &event.NoticeEvent{}
+		}
+	default:
+		notice = &event.NoticeEvent{}
+	}
+
+	if err := decodeEvent(data, notice); err != nil {
+		return nil, err
+	}
+	notice.Base().RawData = data
+	return notice, nil
+}
+
+// decodeEvent 将上报的原始数据解码到具体的事件结构体
+func decodeEvent(data map[string]interface{}, v interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
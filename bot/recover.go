@@ -0,0 +1,83 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/logging"
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+// safeCall 执行一次插件调用，捕获 panic 并记录到插件的失败统计中
+// fn 返回插件的处理函数是否实际执行了，只有实际执行且没有 panic 时才记录为一次正常执行，
+// 插件被关闭或不匹配时不会清零连续 panic 次数
+// 返回 false 表示插件已被禁用或本次调用发生了 panic
+func safeCall(name string, trigger interface{}, fn func() bool) (ok bool) {
+	if plugin.IsFailureDisabled(name) {
+		return false
+	}
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		ok = false
+		logger := eventLogger(trigger).With(logging.KeyPlugin, name)
+		logger.Error("插件发生panic", "panic", r, "event", describeEvent(trigger), "stack", string(debug.Stack()))
+		if plugin.RecordPanic(name, r) {
+			logger.Error("插件连续发生panic，已被自动禁用")
+		}
+	}()
+
+	if fn() {
+		plugin.RecordSuccess(name)
+	}
+	return true
+}
+
+// describeEvent 生成用于日志的事件描述
+func describeEvent(trigger interface{}) string {
+	switch e := trigger.(type) {
+	case *event.MessageEvent:
+		if e.GroupID != 0 {
+			return fmt.Sprintf("[群:%d] 用户:%d 消息ID:%d 消息:%s", e.GroupID, e.UserID, e.MessageID, e.Message)
+		}
+		return fmt.Sprintf("[私聊] 用户:%d 消息ID:%d 消息:%s", e.UserID, e.MessageID, e.Message)
+	case event.Notice:
+		base := e.Base()
+		return fmt.Sprintf("[通知] 类型:%s 子类型:%s 群:%d 用户:%d", base.NoticeType, base.SubType, base.GroupID, base.UserID)
+	case *event.RequestEvent:
+		return fmt.Sprintf("[请求] 类型:%s 子类型:%s 群:%d 用户:%d", e.RequestType, e.SubType, e.GroupID, e.UserID)
+	case event.Meta:
+		return fmt.Sprintf("[元事件] 类型:%s", e.Base().MetaEventType)
+	}
+	return fmt.Sprintf("%+v", trigger)
+}
+
+// eventLogger 返回带有事件的机器人、群和用户字段的日志记录器
+func eventLogger(trigger interface{}) *slog.Logger {
+	var selfID, groupID, userID int64
+	switch e := trigger.(type) {
+	case *event.MessageEvent:
+		selfID, groupID, userID = e.SelfID, e.GroupID, e.UserID
+	case event.Notice:
+		base := e.Base()
+		selfID, groupID, userID = base.SelfID, base.GroupID, base.UserID
+	case *event.RequestEvent:
+		selfID, groupID, userID = e.SelfID, e.GroupID, e.UserID
+	case event.Meta:
+		selfID = e.Base().SelfID
+	}
+
+	attrs := []any{logging.KeySelfID, selfID}
+	if groupID != 0 {
+		attrs = append(attrs, logging.KeyGroupID, groupID)
+	}
+	if userID != 0 {
+		attrs = append(attrs, logging.KeyUserID, userID)
+	}
+	return logging.Logger().With(attrs...)
+}
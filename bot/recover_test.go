@@ -0,0 +1,41 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+func TestSafeCallRecordsSuccessOnlyWhenRun(t *testing.T) {
+	plugin.RegisterReply("recover_test_boom", func(bot plugin.Bot, e *event.MessageEvent) *plugin.Reply {
+		if e.Message == "/boom" {
+			panic("boom")
+		}
+		return nil
+	}, plugin.Command("boom"))
+	t.Cleanup(func() {
+		plugin.Unregister("recover_test_boom")
+		plugin.ResetFailures("recover_test_boom")
+	})
+
+	b, _ := newRecordingBot()
+	consecutive := func() int64 { return plugin.GetFailures()["recover_test_boom"].Consecutive }
+
+	b.dispatch(&event.MessageEvent{MessageType: "private", UserID: 111222333, Message: "/boom", RawMessage: "/boom"})
+	if got := consecutive(); got != 1 {
+		t.Fatalf("Expected 1 consecutive panic, got %d", got)
+	}
+
+	// 不匹配的消息没有执行插件，不清零连续 panic 次数
+	b.dispatch(&event.MessageEvent{MessageType: "private", UserID: 111222333, Message: "hello", RawMessage: "hello"})
+	if got := consecutive(); got != 1 {
+		t.Errorf("Expected unmatched messages to keep the consecutive panics, got %d", got)
+	}
+
+	// 插件正常执行后清零
+	b.dispatch(&event.MessageEvent{MessageType: "private", UserID: 111222333, Message: "/boom now", RawMessage: "/boom now"})
+	if got := consecutive(); got != 0 {
+		t.Errorf("Expected a normal run to reset the consecutive panics, got %d", got)
+	}
+}
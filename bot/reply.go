@@ -0,0 +1,70 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/iamlibie/milonra-go/api"
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/logging"
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+// sendReply 将插件的回复依次发送到触发消息所在的群聊或私聊
+func (b *Bot) sendReply(ctx context.Context, evt *event.MessageEvent, reply *plugin.Reply) {
+	quoted := false
+	for _, msg := range reply.Messages {
+		if isEmptyMessage(msg) {
+			continue
+		}
+		if reply.Quote && !quoted && evt.MessageID != 0 {
+			msg = quoteMessage(msg, evt.MessageID)
+			quoted = true
+		}
+		if err := b.send(ctx, evt.GroupID, evt.UserID, msg); err != nil {
+			plugin.LoggerOf(ctx).Error("发送消息失败", logging.KeyError, err)
+			return
+		}
+	}
+}
+
+// send 发送消息，groupID 不为0时发送到群聊，否则发送到 userID 的私聊
+func (b *Bot) send(ctx context.Context, groupID, userID int64, message interface{}) error {
+	bot := plugin.WithContext(b, ctx)
+	var err error
+	if groupID != 0 {
+		_, err = api.SendGroupMessage(bot, groupID, message)
+	} else {
+		_, err = api.SendPrivateMessage(bot, userID, message)
+	}
+	return err
+}
+
+// isEmptyMessage 判断消息是否为空（nil、空字符串或没有消息段）
+func isEmptyMessage(msg interface{}) bool {
+	switch v := msg.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case *api.Message:
+		return v == nil || len(v.Build()) == 0
+	case []api.MessageSegment:
+		return len(v) == 0
+	}
+	return false
+}
+
+// quoteMessage 在消息前添加引用回复消息段
+func quoteMessage(msg interface{}, messageID int32) interface{} {
+	reply := api.NewMessage().Reply(messageID).Build()
+	switch v := msg.(type) {
+	case string:
+		return fmt.Sprintf("[CQ:reply,id=%d]%s", messageID, v)
+	case *api.Message:
+		return append(reply, v.Build()...)
+	case []api.MessageSegment:
+		return append(reply, v...)
+	}
+	return msg
+}
@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/iamlibie/milonra-go/api"
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+// recordingTransport records every API call and answers it immediately
+type recordingTransport struct {
+	bot *Bot
+
+	mu    sync.Mutex
+	calls []map[string]interface{}
+}
+
+func (t *recordingTransport) WriteJSON(v interface{}) error {
+	data := v.(map[string]interface{})
+	t.mu.Lock()
+	t.calls = append(t.calls, data)
+	t.mu.Unlock()
+	t.bot.Responses().HandleAPIResponse(map[string]interface{}{
+		"status":  "ok",
+		"retcode": float64(0),
+		"data":    map[string]interface{}{"message_id": float64(1)},
+		"echo":    data["echo"],
+	})
+	return nil
+}
+
+func newRecordingBot() (*Bot, *recordingTransport) {
+	t := &recordingTransport{}
+	b := &Bot{SelfID: 10001, Transport: t}
+	t.bot = b
+	return b, t
+}
+
+func TestQuoteMessage(t *testing.T) {
+	reply := api.MessageSegment{Type: "reply", Data: map[string]interface{}{"id": "42"}}
+	text := api.MessageSegment{Type: "text", Data: map[string]interface{}{"text": "hello"}}
+
+	if got := quoteMessage("hello", 42); got != "[CQ:reply,id=42]hello" {
+		t.Errorf("quoteMessage(string) = %q", got)
+	}
+
+	got := quoteMessage(api.NewMessage().Text("hello"), 42)
+	if want := []api.MessageSegment{reply, text}; !reflect.DeepEqual(got, want) {
+		t.Errorf("quoteMessage(*Message) = %v, want %v", got, want)
+	}
+
+	segments := []api.MessageSegment{text}
+	got = quoteMessage(segments, 42)
+	if want := []api.MessageSegment{reply, text}; !reflect.DeepEqual(got, want) {
+		t.Errorf("quoteMessage([]MessageSegment) = %v, want %v", got, want)
+	}
+	if len(segments) != 1 || segments[0].Type != "text" {
+		t.Errorf("Expected the original segments to be unchanged, got %v", segments)
+	}
+
+	// 不支持的类型原样返回
+	if got := quoteMessage(42, 1); got != 42 {
+		t.Errorf("quoteMessage(int) = %v, want 42", got)
+	}
+}
+
+func TestIsEmptyMessage(t *testing.T) {
+	var nilMessage *api.Message
+	tests := []struct {
+		name  string
+		msg   interface{}
+		empty bool
+	}{
+		{"nil", nil, true},
+		{"empty string", "", true},
+		{"string", "hello", false},
+		{"nil message", nilMessage, true},
+		{"empty message", api.NewMessage(), true},
+		{"message", api.NewMessage().Text("hello"), false},
+		{"no segments", []api.MessageSegment{}, true},
+		{"segments", []api.MessageSegment{{Type: "face", Data: map[string]interface{}{"id": "1"}}}, false},
+		{"other type", 42, false},
+	}
+	for _, tt := range tests {
+		if got := isEmptyMessage(tt.msg); got != tt.empty {
+			t.Errorf("%s: isEmptyMessage = %v, want %v", tt.name, got, tt.empty)
+		}
+	}
+}
+
+func TestSendReply(t *testing.T) {
+	b, transport := newRecordingBot()
+	evt := &event.MessageEvent{MessageID: 42, MessageType: "group", GroupID: 555666777, UserID: 111222333}
+
+	// 多条消息依次发送，跳过空消息，只有第一条发送的消息带引用
+	reply := plugin.NewReply("", "first", api.NewMessage(), api.NewMessage().Text("second"), nil, "third").Quoted()
+	b.sendReply(context.Background(), evt, reply)
+
+	if len(transport.calls) != 3 {
+		t.Fatalf("Expected 3 messages, got %d: %v", len(transport.calls), transport.calls)
+	}
+	for _, call := range transport.calls {
+		params := call["params"].(map[string]interface{})
+		if call["action"] != "send_group_msg" || params["group_id"] != int64(555666777) {
+			t.Errorf("Unexpected call: %v", call)
+		}
+	}
+	messages := make([]interface{}, len(transport.calls))
+	for i, call := range transport.calls {
+		messages[i] = call["params"].(map[string]interface{})["message"]
+	}
+	if messages[0] != "[CQ:reply,id=42]first" {
+		t.Errorf("Expected the first message to be quoted, got %v", messages[0])
+	}
+	if segments, ok := messages[1].([]api.MessageSegment); !ok || len(segments) != 1 || segments[0].Type != "text" {
+		t.Errorf("Expected the second message without quote, got %v", messages[1])
+	}
+	if messages[2] != "third" {
+		t.Errorf("Expected the third message without quote, got %v", messages[2])
+	}
+
+	// 私聊回复，没有消息ID时不添加引用
+	transport.calls = nil
+	private := &event.MessageEvent{MessageType: "private", UserID: 111222333}
+	b.sendReply(context.Background(), private, plugin.NewReply("hi").Quoted())
+	if len(transport.calls) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(transport.calls))
+	}
+	params := transport.calls[0]["params"].(map[string]interface{})
+	if transport.calls[0]["action"] != "send_private_msg" || params["user_id"] != int64(111222333) || params["message"] != "hi" {
+		t.Errorf("Unexpected private reply: %v", transport.calls[0])
+	}
+}
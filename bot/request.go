@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"github.com/iamlibie/milonra-go/api"
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/filter"
+	"github.com/iamlibie/milonra-go/logging"
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+// handleRequestEvent 处理请求事件
+func (b *Bot) handleRequestEvent(data map[string]interface{}) {
+	req := &event.RequestEvent{}
+	if err := decodeEvent(data, req); err != nil {
+		logging.Logger().Error("解析请求事件失败", logging.KeySelfID, b.GetSelfID(), logging.KeyError, err)
+		return
+	}
+	req.RawData = data
+	if !filter.Allowed(req.GroupID, req.UserID) {
+		return
+	}
+
+	eventLogger(req).Info("收到请求", "request_type", req.RequestType, "sub_type", req.SubType, "comment", req.Comment)
+
+	// 请求插件按注册顺序依次执行，第一个给出处理结果的插件生效
+	go func(req *event.RequestEvent) {
+		for _, p := range plugin.GetRequestPlugins() {
+			if !plugin.IsEnabled(p.Name, req.GroupID, req.UserID) {
+				continue
+			}
+			handled := false
+			safeCall(p.Name, req, func() bool {
+				handled = b.runRequestPlugin(p.Name, p.Func, req)
+				return true
+			})
+			if handled {
+				return
+			}
+		}
+	}(req)
+}
+
+// runRequestPlugin 调用单个请求插件，返回插件是否给出了处理结果
+func (b *Bot) runRequestPlugin(name string, requestFunc plugin.RequestFunc, req *event.RequestEvent) bool {
+	ctx, cancel := b.pluginContext(name, req)
+	defer cancel()
+
+	bot := plugin.WithContext(b, ctx)
+	reply := requestFunc(bot, req)
+	if reply == nil {
+		return false
+	}
+	logger := plugin.LoggerOf(ctx)
+	logger.Info("请求插件处理了请求", "approve", reply.Approve)
+	if err := replyRequest(bot, req, reply); err != nil {
+		logger.Error("处理请求失败", logging.KeyError, err)
+	}
+	return true
+}
+
+// replyRequest 将请求插件的处理结果转换为对应的 API 调用
+func replyRequest(bot plugin.Bot, req *event.RequestEvent, reply *plugin.RequestReply) error {
+	switch req.RequestType {
+	case event.RequestFriend:
+		return api.SetFriendAddRequest(bot, req.Flag, reply.Approve, reply.Remark)
+	case event.RequestGroup:
+		return api.SetGroupAddRequest(bot, req.Flag, req.SubType, reply.Approve, reply.Reason)
+	default:
+		eventLogger(req).Warn("未知的请求类型", "request_type", req.RequestType)
+		return nil
+	}
+}
@@ -1,12 +1,45 @@
 package event
 
+import "strings"
+
 type MessageEvent struct {
-	GroupID    int64                  `json:"group_id"`
-	UserID     int64                  `json:"user_id"`
-	Message    string                 `json:"message"`
-	RawMessage string                 `json:"raw_message"` // 原始消息（带 CQ 码）
-	Nickname   string                 `json:"nickname"`
-	Time       int64                  `json:"time"`
-	IsAtMe     bool                   `json:"is_at_me"` // 是否 @ 了机器人
-	RawData    map[string]interface{} // 原始 JSON 数据
+	MessageID   int32                  `json:"message_id"`   // 消息ID，可用于回复引用和撤回
+	MessageType string                 `json:"message_type"` // group 或 private
+	SubType     string                 `json:"sub_type"`     // 群聊: normal、anonymous、notice；私聊: friend、group、other
+	SelfID      int64                  `json:"self_id"`      // 收到消息的机器人QQ号
+	GroupID     int64                  `json:"group_id"`
+	UserID      int64                  `json:"user_id"`
+	Message     string                 `json:"message"`
+	RawMessage  string                 `json:"raw_message"` // 原始消息（带 CQ 码）
+	Segments    []MessageSegment       `json:"segments"`    // 解析后的消息段
+	Nickname    string                 `json:"nickname"`
+	Sender      Sender                 `json:"sender"`    // 发送者信息（群聊时包含角色、群名片、头衔）
+	Anonymous   *Anonymous             `json:"anonymous"` // 匿名信息，非匿名消息为 nil
+	Time        int64                  `json:"time"`
+	IsAtMe      bool                   `json:"is_at_me"` // 是否 @ 了机器人
+	RawData     map[string]interface{} // 原始 JSON 数据
+}
+
+// IsGroup 是否为群聊消息
+func (e *MessageEvent) IsGroup() bool {
+	return e.GroupID != 0
+}
+
+// IsPrivate 是否为私聊消息
+func (e *MessageEvent) IsPrivate() bool {
+	return e.GroupID == 0
+}
+
+// PlainText 返回消息中所有文本段拼接后的纯文本（不含 @、图片等）
+func (e *MessageEvent) PlainText() string {
+	var sb strings.Builder
+	for _, seg := range e.Segments {
+		if seg.Type != "text" {
+			continue
+		}
+		if text, ok := seg.Data["text"].(string); ok {
+			sb.WriteString(text)
+		}
+	}
+	return sb.String()
 }
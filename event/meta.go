@@ -0,0 +1,48 @@
+package event
+
+// 元事件类型（meta_event_type）
+const (
+	MetaLifecycle = "lifecycle" // 生命周期
+	MetaHeartbeat = "heartbeat" // 心跳
+)
+
+// Meta 元事件接口，具体类型为 *LifecycleEvent、*HeartbeatEvent 或未识别时的 *MetaEvent
+type Meta interface {
+	Base() *MetaEvent
+}
+
+// MetaEvent 元事件的公共字段
+type MetaEvent struct {
+	Time          int64                  `json:"time"`
+	SelfID        int64                  `json:"self_id"`
+	PostType      string                 `json:"post_type"`
+	MetaEventType string                 `json:"meta_event_type"`
+	RawData       map[string]interface{} `json:"-"` // 原始 JSON 数据
+}
+
+// Base 返回元事件的公共字段
+func (e *MetaEvent) Base() *MetaEvent {
+	return e
+}
+
+// LifecycleEvent 生命周期事件，SubType 为 enable、disable 或 connect
+type LifecycleEvent struct {
+	MetaEvent
+	SubType string `json:"sub_type"`
+}
+
+// HeartbeatStatus 心跳事件中携带的 OneBot 实现运行状态
+type HeartbeatStatus struct {
+	AppInitialized bool `json:"app_initialized"`
+	AppEnabled     bool `json:"app_enabled"`
+	AppGood        bool `json:"app_good"`
+	Online         bool `json:"online"` // 是否在线
+	Good           bool `json:"good"`   // 状态是否符合预期
+}
+
+// HeartbeatEvent 心跳事件
+type HeartbeatEvent struct {
+	MetaEvent
+	Status   HeartbeatStatus `json:"status"`
+	Interval int64           `json:"interval"` // 到下次心跳的间隔（毫秒）
+}
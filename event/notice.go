@@ -0,0 +1,127 @@
+package event
+
+// 通知类型（notice_type）
+const (
+	NoticeGroupUpload   = "group_upload"   // 群文件上传
+	NoticeGroupAdmin    = "group_admin"    // 群管理员变动
+	NoticeGroupDecrease = "group_decrease" // 群成员减少
+	NoticeGroupIncrease = "group_increase" // 群成员增加
+	NoticeGroupBan      = "group_ban"      // 群禁言
+	NoticeFriendAdd     = "friend_add"     // 好友添加
+	NoticeGroupRecall   = "group_recall"   // 群消息撤回
+	NoticeFriendRecall  = "friend_recall"  // 好友消息撤回
+	NoticeGroupCard     = "group_card"     // 群名片变更
+	NoticeNotify        = "notify"         // 提醒事件（戳一戳、红包运气王、群荣誉）
+)
+
+// Notice 通知事件接口，所有具体的通知事件类型都实现了该接口
+//
+// 插件可以通过类型断言获取具体的事件：
+//
+//	switch n := e.(type) {
+//	case *event.GroupIncreaseNotice:
+//	    // 新成员入群
+//	case *event.PokeNotice:
+//	    // 戳一戳
+//	}
+type Notice interface {
+	Base() *NoticeEvent
+}
+
+// NoticeEvent 通知事件的公共字段，未识别的通知类型也会以该类型分发
+type NoticeEvent struct {
+	Time       int64                  `json:"time"`
+	SelfID     int64                  `json:"self_id"`
+	PostType   string                 `json:"post_type"`
+	NoticeType string                 `json:"notice_type"`
+	SubType    string                 `json:"sub_type"`
+	GroupID    int64                  `json:"group_id"` // 群号（好友相关通知为0）
+	UserID     int64                  `json:"user_id"`
+	RawData    map[string]interface{} `json:"-"` // 原始 JSON 数据
+}
+
+// Base 返回通知事件的公共字段
+func (e *NoticeEvent) Base() *NoticeEvent {
+	return e
+}
+
+// UploadFile 群文件上传通知中的文件信息
+type UploadFile struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Size  int64  `json:"size"`
+	Busid int64  `json:"busid"`
+}
+
+// GroupUploadNotice 群文件上传
+type GroupUploadNotice struct {
+	NoticeEvent
+	File UploadFile `json:"file"`
+}
+
+// GroupAdminNotice 群管理员变动，SubType 为 set 或 unset
+type GroupAdminNotice struct {
+	NoticeEvent
+}
+
+// GroupDecreaseNotice 群成员减少，SubType 为 leave、kick 或 kick_me
+type GroupDecreaseNotice struct {
+	NoticeEvent
+	OperatorID int64 `json:"operator_id"` // 操作者QQ号（主动退群时与 UserID 相同）
+}
+
+// GroupIncreaseNotice 群成员增加，SubType 为 approve 或 invite
+type GroupIncreaseNotice struct {
+	NoticeEvent
+	OperatorID int64 `json:"operator_id"` // 操作者QQ号
+}
+
+// GroupBanNotice 群禁言，SubType 为 ban 或 lift_ban
+type GroupBanNotice struct {
+	NoticeEvent
+	OperatorID int64 `json:"operator_id"` // 操作者QQ号
+	Duration   int64 `json:"duration"`    // 禁言时长（秒），UserID 为0时表示全员禁言
+}
+
+// FriendAddNotice 好友添加
+type FriendAddNotice struct {
+	NoticeEvent
+}
+
+// GroupRecallNotice 群消息撤回
+type GroupRecallNotice struct {
+	NoticeEvent
+	OperatorID int64 `json:"operator_id"` // 操作者QQ号
+	MessageID  int32 `json:"message_id"`  // 被撤回的消息ID
+}
+
+// FriendRecallNotice 好友消息撤回
+type FriendRecallNotice struct {
+	NoticeEvent
+	MessageID int32 `json:"message_id"` // 被撤回的消息ID
+}
+
+// GroupCardNotice 群名片变更
+type GroupCardNotice struct {
+	NoticeEvent
+	CardNew string `json:"card_new"`
+	CardOld string `json:"card_old"`
+}
+
+// PokeNotice 戳一戳（notify/poke），私聊戳一戳时 GroupID 为0
+type PokeNotice struct {
+	NoticeEvent
+	TargetID int64 `json:"target_id"` // 被戳者QQ号
+}
+
+// LuckyKingNotice 群红包运气王（notify/lucky_king）
+type LuckyKingNotice struct {
+	NoticeEvent
+	TargetID int64 `json:"target_id"` // 运气王QQ号
+}
+
+// HonorNotice 群成员荣誉变更（notify/honor）
+type HonorNotice struct {
+	NoticeEvent
+	HonorType string `json:"honor_type"` // talkative、performer、emotion
+}
@@ -0,0 +1,31 @@
+package event
+
+// 请求类型（request_type）
+const (
+	RequestFriend = "friend" // 加好友请求
+	RequestGroup  = "group"  // 加群请求或邀请
+)
+
+// RequestEvent 请求事件（加好友请求、加群请求、邀请入群）
+type RequestEvent struct {
+	Time        int64                  `json:"time"`
+	SelfID      int64                  `json:"self_id"`
+	PostType    string                 `json:"post_type"`
+	RequestType string                 `json:"request_type"` // friend 或 group
+	SubType     string                 `json:"sub_type"`     // 加群请求时为 add（申请入群）或 invite（邀请机器人入群）
+	UserID      int64                  `json:"user_id"`      // 发送请求的QQ号
+	GroupID     int64                  `json:"group_id"`     // 群号（好友请求时为0）
+	Comment     string                 `json:"comment"`      // 验证信息
+	Flag        string                 `json:"flag"`         // 请求 flag，处理请求时需要传入
+	RawData     map[string]interface{} `json:"-"`            // 原始 JSON 数据
+}
+
+// IsFriend 是否为加好友请求
+func (e *RequestEvent) IsFriend() bool {
+	return e.RequestType == RequestFriend
+}
+
+// IsGroup 是否为加群请求或邀请
+func (e *RequestEvent) IsGroup() bool {
+	return e.RequestType == RequestGroup
+}
@@ -0,0 +1,45 @@
+package event
+
+// MessageSegment 消息段
+type MessageSegment struct {
+	Type string                 `json:"type"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// Sender 发送者信息
+type Sender struct {
+	UserID   int64  `json:"user_id"`
+	Nickname string `json:"nickname"`
+	Sex      string `json:"sex,omitempty"`
+	Age      int64  `json:"age,omitempty"`
+	Card     string `json:"card,omitempty"`  // 群名片
+	Area     string `json:"area,omitempty"`  // 地区
+	Level    string `json:"level,omitempty"` // 成员等级
+	Role     string `json:"role,omitempty"`  // 角色 owner/admin/member
+	Title    string `json:"title,omitempty"` // 专属头衔
+}
+
+// IsOwner 是否为群主
+func (s *Sender) IsOwner() bool {
+	return s.Role == "owner"
+}
+
+// IsAdmin 是否为群管理员或群主
+func (s *Sender) IsAdmin() bool {
+	return s.Role == "admin" || s.Role == "owner"
+}
+
+// DisplayName 优先返回群名片，没有群名片时返回昵称
+func (s *Sender) DisplayName() string {
+	if s.Card != "" {
+		return s.Card
+	}
+	return s.Nickname
+}
+
+// Anonymous 匿名信息
+type Anonymous struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Flag string `json:"flag"`
+}
@@ -2,8 +2,9 @@
 package myplugins
 
 import (
+	"context"
 	"fmt"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/iamlibie/milonra-go/api"
@@ -11,52 +12,40 @@ import (
 	"github.com/iamlibie/milonra-go/plugin"
 )
 
-// 天气查询插件示例
-func WeatherPlugin(bot plugin.Bot, e *event.MessageEvent) string {
-	if strings.HasPrefix(e.Message, "天气 ") {
-		city := strings.TrimPrefix(e.Message, "天气 ")
-		// 这里应该调用实际的天气API
-		return fmt.Sprintf("📍 %s 的天气：☀️ 晴天 25°C", city)
+// 天气查询插件示例：/天气 北京
+func WeatherPlugin(ctx context.Context, bot plugin.Bot, e *event.MessageEvent, m *plugin.Match) *plugin.Reply {
+	if m.Args == "" {
+		return plugin.NewReply("❌ 请输入城市，格式：/天气 北京")
 	}
-	return ""
+	// 这里应该调用实际的天气API
+	return plugin.NewReply(fmt.Sprintf("📍 %s 的天气：☀️ 晴天 25°C", m.Args))
 }
 
-// 计算器插件示例
-func CalculatorPlugin(bot plugin.Bot, e *event.MessageEvent) string {
-	if strings.HasPrefix(e.Message, "计算 ") {
-		expression := strings.TrimPrefix(e.Message, "计算 ")
-		// 简单的加法示例
-		if strings.Contains(expression, "+") {
-			parts := strings.Split(expression, "+")
-			if len(parts) == 2 {
-				return fmt.Sprintf("💡 %s = 结果（请实现实际计算逻辑）", expression)
-			}
-		}
-		return "❌ 暂只支持简单加法，格式：计算 1+2"
-	}
-	return ""
+// 计算器插件示例：计算 1+2，通过正则捕获两个加数
+func CalculatorPlugin(ctx context.Context, bot plugin.Bot, e *event.MessageEvent, m *plugin.Match) *plugin.Reply {
+	a, _ := strconv.Atoi(m.Named["a"])
+	b, _ := strconv.Atoi(m.Named["b"])
+	return plugin.NewReply(fmt.Sprintf("💡 %d + %d = %d", a, b, a+b))
 }
 
-// 定时提醒插件示例
-func ReminderPlugin(bot plugin.Bot, e *event.MessageEvent) string {
-	if strings.HasPrefix(e.Message, "提醒 ") {
-		reminder := strings.TrimPrefix(e.Message, "提醒 ")
+// 定时提醒插件示例：/提醒 5m 喝水
+func ReminderCommand(ctx context.Context, bot plugin.Bot, e *event.MessageEvent, args *plugin.Args) *plugin.Reply {
+	delay := args.Duration("时长")
+	reminder := args.String("内容")
 
-		// 启动异步定时任务（示例：5秒后提醒）
-		go func() {
-			time.Sleep(5 * time.Second)
-			msg := fmt.Sprintf("⏰ 提醒：%s", reminder)
+	// 启动异步定时任务
+	go func() {
+		time.Sleep(delay)
+		msg := fmt.Sprintf("⏰ 提醒：%s", reminder)
 
-			if e.GroupID != 0 {
-				api.SendGroupMessage(bot, e.GroupID, msg)
-			} else {
-				api.SendPrivateMessage(bot, e.UserID, msg)
-			}
-		}()
+		if e.GroupID != 0 {
+			api.SendGroupMessage(bot, e.GroupID, msg)
+		} else {
+			api.SendPrivateMessage(bot, e.UserID, msg)
+		}
+	}()
 
-		return "✅ 已设置提醒，将在5秒后提醒您"
-	}
-	return ""
+	return plugin.NewReply(fmt.Sprintf("✅ 已设置提醒，将在%s后提醒您", delay))
 }
 
 // 管理员插件示例
@@ -84,9 +73,37 @@ func AdminPlugin(bot plugin.Bot, e *event.MessageEvent) string {
 	return ""
 }
 
+// 签到插件示例：@发送者并引用其消息回复
+func SignInPlugin(bot plugin.Bot, e *event.MessageEvent) *plugin.Reply {
+	if e.Message != "签到" {
+		return nil
+	}
+	msg := api.NewMessage().At(e.UserID).Text(" 签到成功！")
+	return plugin.NewReply(msg).Quoted()
+}
+
+// 入群欢迎插件示例
+func WelcomePlugin(bot plugin.Bot, e event.Notice) string {
+	if n, ok := e.(*event.GroupIncreaseNotice); ok {
+		return fmt.Sprintf("🎉 欢迎新成员 %d 加入本群！", n.UserID)
+	}
+	return ""
+}
+
 func init() {
-	plugin.Register("weather", WeatherPlugin)
-	plugin.Register("calculator", CalculatorPlugin)
-	plugin.Register("reminder", ReminderPlugin)
+	plugin.RegisterMatcher("weather", WeatherPlugin, plugin.Command("天气", "weather"))
+	plugin.RegisterMatcher("calculator", CalculatorPlugin, plugin.Regex(`^计算\s*(?P<a>\d+)\s*\+\s*(?P<b>\d+)$`))
+	plugin.RegisterCommands("reminder", []plugin.CommandSpec{{
+		Name:        "提醒",
+		Aliases:     []string{"remind"},
+		Description: "在指定时间后提醒您",
+		Args: []plugin.ArgSpec{
+			{Name: "时长", Type: plugin.ArgDuration, Help: "如 30s、5m、1h"},
+			{Name: "内容", Type: plugin.ArgText},
+		},
+		Handler: ReminderCommand,
+	}})
 	plugin.Register("admin", AdminPlugin)
+	plugin.RegisterReply("signin", SignInPlugin)
+	plugin.RegisterNotice("welcome", WelcomePlugin)
 }
@@ -0,0 +1,103 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+// PluginName 内置名单管理插件的名称，该插件始终启用
+const PluginName = "filter_manager"
+
+// RegisterCommands 注册内置的名单管理命令（仅超级用户）：
+//
+//	/黑名单 <添加|移除> <用户|群> <ID>
+//	/白名单 <添加|移除> <用户|群> <ID>
+//	/名单                              查看所有名单
+func RegisterCommands() {
+	plugin.SetAlwaysEnabled(PluginName)
+	editArgs := []plugin.ArgSpec{
+		{Name: "操作", Type: plugin.ArgString, Help: "添加（add）或移除（remove）"},
+		{Name: "类型", Type: plugin.ArgString, Help: "用户（user）或群（group）"},
+		{Name: "ID", Type: plugin.ArgUser, Help: "@用户、QQ号或群号"},
+	}
+	plugin.RegisterCommands(PluginName, []plugin.CommandSpec{
+		{
+			Name:        "黑名单",
+			Aliases:     []string{"deny"},
+			Description: "编辑黑名单，名单中的用户或群的消息会被忽略",
+			Args:        editArgs,
+			Permission:  plugin.PermSuperuser,
+			Handler:     editCommand(DenyUsers, DenyGroups),
+		},
+		{
+			Name:        "白名单",
+			Aliases:     []string{"allow"},
+			Description: "编辑白名单，名单不为空时只处理名单中的用户或群",
+			Args:        editArgs,
+			Permission:  plugin.PermSuperuser,
+			Handler:     editCommand(AllowUsers, AllowGroups),
+		},
+		{
+			Name:        "名单",
+			Aliases:     []string{"lists"},
+			Description: "查看黑白名单",
+			Permission:  plugin.PermSuperuser,
+			Handler:     listCommand,
+		},
+	})
+}
+
+// editCommand 返回编辑名单的命令处理函数
+func editCommand(users, groups List) plugin.CommandFunc {
+	return func(ctx context.Context, bot plugin.Bot, e *event.MessageEvent, args *plugin.Args) *plugin.Reply {
+		var list List
+		switch args.String("类型") {
+		case "用户", "user":
+			list = users
+		case "群", "group":
+			list = groups
+		default:
+			return plugin.NewReply("❌ 类型应为 用户 或 群")
+		}
+
+		id := args.User("ID")
+		var err error
+		var action string
+		switch args.String("操作") {
+		case "添加", "add":
+			err, action = Add(list, id), "添加到"
+		case "移除", "remove":
+			err, action = Remove(list, id), "移出"
+		default:
+			return plugin.NewReply("❌ 操作应为 添加 或 移除")
+		}
+		if err != nil {
+			return plugin.NewReply(fmt.Sprintf("❌ %v", err))
+		}
+		return plugin.NewReply(fmt.Sprintf("✅ 已将 %d %s%s", id, action, list))
+	}
+}
+
+// listCommand 列出所有名单
+func listCommand(ctx context.Context, bot plugin.Bot, e *event.MessageEvent, args *plugin.Args) *plugin.Reply {
+	lists := Get()
+	var sb strings.Builder
+	sb.WriteString("📋 黑白名单")
+	for _, list := range []List{AllowUsers, DenyUsers, AllowGroups, DenyGroups} {
+		ids := *lists.get(list)
+		if len(ids) == 0 {
+			fmt.Fprintf(&sb, "\n%s: 无", list)
+			continue
+		}
+		strs := make([]string, len(ids))
+		for i, id := range ids {
+			strs[i] = fmt.Sprint(id)
+		}
+		fmt.Fprintf(&sb, "\n%s: %s", list, strings.Join(strs, ", "))
+	}
+	return plugin.NewReply(sb.String())
+}
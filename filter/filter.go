@@ -0,0 +1,216 @@
+// Package filter 实现全局的用户、群黑白名单，在事件分发给插件之前过滤
+package filter
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/iamlibie/milonra-go/internal/store"
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+// List 名单类型
+type List string
+
+const (
+	AllowUsers  List = "allow_users"  // 用户白名单，不为空时只处理名单中用户的事件
+	DenyUsers   List = "deny_users"   // 用户黑名单，忽略名单中用户的所有事件
+	AllowGroups List = "allow_groups" // 群白名单，不为空时只处理名单中群的事件（私聊不受影响）
+	DenyGroups  List = "deny_groups"  // 群黑名单，忽略名单中群的所有事件
+)
+
+// String 返回名单的中文名称
+func (l List) String() string {
+	switch l {
+	case AllowUsers:
+		return "用户白名单"
+	case DenyUsers:
+		return "用户黑名单"
+	case AllowGroups:
+		return "群白名单"
+	case DenyGroups:
+		return "群黑名单"
+	}
+	return string(l)
+}
+
+// Lists 黑白名单
+type Lists struct {
+	AllowUsers  []int64 `json:"allow_users"`
+	DenyUsers   []int64 `json:"deny_users"`
+	AllowGroups []int64 `json:"allow_groups"`
+	DenyGroups  []int64 `json:"deny_groups"`
+}
+
+// get 返回指定名单
+func (l *Lists) get(list List) *[]int64 {
+	switch list {
+	case AllowUsers:
+		return &l.AllowUsers
+	case DenyUsers:
+		return &l.DenyUsers
+	case AllowGroups:
+		return &l.AllowGroups
+	case DenyGroups:
+		return &l.DenyGroups
+	}
+	return nil
+}
+
+// 生效的名单为配置文件中的名单与运行时修改的名单的并集
+var (
+	base     Lists // 配置文件中的名单
+	runtime  Lists // 通过命令或 SDK 添加的名单，保存在文件中
+	file     string
+	modTime  time.Time
+	filterMu sync.RWMutex
+)
+
+// SetBase 设置配置文件中的名单，重新加载配置时再次调用即可生效
+func SetBase(lists Lists) {
+	filterMu.Lock()
+	defer filterMu.Unlock()
+	base = lists
+}
+
+// Load 从文件加载运行时修改的名单，之后的修改都会保存到该文件，文件不存在时使用空名单
+func Load(path string) error {
+	lists := Lists{}
+	if err := store.Load(path, &lists); err != nil {
+		return err
+	}
+
+	filterMu.Lock()
+	defer filterMu.Unlock()
+
+	runtime = lists
+	file = path
+	modTime = fileModTime(path)
+	return nil
+}
+
+// Allowed 判断是否处理来自该群（私聊为0）和用户的事件，超级用户不受名单限制
+func Allowed(groupID, userID int64) bool {
+	if plugin.IsSuperuser(userID) {
+		return true
+	}
+
+	filterMu.RLock()
+	defer filterMu.RUnlock()
+
+	if userID != 0 {
+		if contains(DenyUsers, userID) {
+			return false
+		}
+		if hasEntries(AllowUsers) && !contains(AllowUsers, userID) {
+			return false
+		}
+	}
+	if groupID != 0 {
+		if contains(DenyGroups, groupID) {
+			return false
+		}
+		if hasEntries(AllowGroups) && !contains(AllowGroups, groupID) {
+			return false
+		}
+	}
+	return true
+}
+
+// Add 向名单中添加ID
+func Add(list List, id int64) error {
+	filterMu.Lock()
+	defer filterMu.Unlock()
+
+	ids := runtime.get(list)
+	if ids == nil {
+		return fmt.Errorf("未知的名单: %s", list)
+	}
+	if contains(list, id) {
+		return nil
+	}
+	*ids = append(*ids, id)
+	return save()
+}
+
+// Remove 从名单中移除ID，配置文件中的ID只能通过修改配置文件移除
+func Remove(list List, id int64) error {
+	filterMu.Lock()
+	defer filterMu.Unlock()
+
+	ids := runtime.get(list)
+	if ids == nil {
+		return fmt.Errorf("未知的名单: %s", list)
+	}
+	for _, baseID := range *base.get(list) {
+		if baseID == id {
+			return fmt.Errorf("%d 位于配置文件的%s中，请修改配置文件", id, list)
+		}
+	}
+	for i, existing := range *ids {
+		if existing == id {
+			*ids = append((*ids)[:i:i], (*ids)[i+1:]...)
+			return save()
+		}
+	}
+	return nil
+}
+
+// Get 返回生效的名单
+func Get() Lists {
+	filterMu.RLock()
+	defer filterMu.RUnlock()
+
+	result := Lists{}
+	for _, list := range []List{AllowUsers, DenyUsers, AllowGroups, DenyGroups} {
+		merged := append([]int64(nil), *base.get(list)...)
+		for _, id := range *runtime.get(list) {
+			if !containsID(merged, id) {
+				merged = append(merged, id)
+			}
+		}
+		*result.get(list) = merged
+	}
+	return result
+}
+
+// contains 生效的名单中是否包含ID，调用前需持有锁
+func contains(list List, id int64) bool {
+	return containsID(*base.get(list), id) || containsID(*runtime.get(list), id)
+}
+
+// hasEntries 生效的名单是否不为空，调用前需持有锁
+func hasEntries(list List) bool {
+	return len(*base.get(list)) > 0 || len(*runtime.get(list)) > 0
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
+
+// save 保存运行时修改的名单，未调用 Load 时只保存在内存中，调用前需持有写锁
+func save() error {
+	if file == "" {
+		return nil
+	}
+	if err := store.Save(file, runtime); err != nil {
+		return err
+	}
+	modTime = fileModTime(file)
+	return nil
+}
+
+func fileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,63 @@
+package filter_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iamlibie/milonra-go/filter"
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+func TestFilterLists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filter.json")
+	if err := filter.Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	defer filter.Load(filepath.Join(t.TempDir(), "empty.json"))
+	filter.SetBase(filter.Lists{DenyGroups: []int64{2002}})
+	defer filter.SetBase(filter.Lists{})
+	plugin.SetSuperusers(99)
+	defer plugin.SetSuperusers()
+
+	if err := filter.Add(filter.DenyUsers, 666); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if filter.Allowed(1001, 666) || filter.Allowed(0, 666) {
+		t.Error("Expected denied user to be filtered everywhere")
+	}
+	if filter.Allowed(2002, 1) || !filter.Allowed(1001, 1) {
+		t.Error("Expected only the denied group to be filtered")
+	}
+	if !filter.Allowed(2002, 99) {
+		t.Error("Expected superusers to bypass the lists")
+	}
+	if err := filter.Remove(filter.DenyGroups, 2002); err == nil {
+		t.Error("Expected removing a config entry to fail")
+	}
+
+	if err := filter.Add(filter.AllowGroups, 3003); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if filter.Allowed(1001, 1) || !filter.Allowed(3003, 1) || !filter.Allowed(0, 1) {
+		t.Error("Expected group allow list to restrict groups but not private chats")
+	}
+
+	// 外部修改名单文件后自动重新加载
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go filter.Watch(ctx, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if err := os.WriteFile(path, []byte(`{"deny_users": [777]}`), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for filter.Allowed(0, 777) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if filter.Allowed(0, 777) || !filter.Allowed(0, 666) {
+		t.Errorf("Expected lists to be reloaded from file, got %+v", filter.Get())
+	}
+}
@@ -0,0 +1,39 @@
+package filter
+
+import (
+	"context"
+	"time"
+
+	"github.com/iamlibie/milonra-go/logging"
+)
+
+// Watch 定期检查名单文件，文件被外部修改时重新加载，直到 ctx 取消
+func Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		filterMu.RLock()
+		path, last := file, modTime
+		filterMu.RUnlock()
+		if path == "" {
+			continue
+		}
+
+		current := fileModTime(path)
+		if current.IsZero() || current.Equal(last) {
+			continue
+		}
+		if err := Load(path); err != nil {
+			logging.Logger().Error("重新加载黑白名单失败", "path", path, logging.KeyError, err)
+			continue
+		}
+		logging.Logger().Info("已重新加载黑白名单", "path", path)
+	}
+}
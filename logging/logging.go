@@ -0,0 +1,130 @@
+// Package logging 提供框架统一使用的结构化日志（log/slog），可以设置级别和格式，也可以替换为自定义的记录器
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// 日志中常用的字段名
+const (
+	KeySelfID  = "self_id"  // 机器人QQ号
+	KeyGroupID = "group_id" // 群号
+	KeyUserID  = "user_id"  // 用户QQ号
+	KeyPlugin  = "plugin"   // 插件名称
+	KeyEcho    = "echo"     // API 请求的 echo
+	KeyError   = "error"    // 错误
+)
+
+var (
+	level   = new(slog.LevelVar)
+	current atomic.Pointer[slog.Logger]
+
+	// base 所有日志都经过它转发给当前的记录器，使替换记录器对已经创建的子记录器同样生效
+	base = slog.New(&forwardHandler{})
+)
+
+func init() {
+	current.Store(newLogger(os.Stderr, "text"))
+}
+
+// Logger 返回框架的日志记录器，之后调用 SetLogger 或 Configure 对它和它派生的记录器同样生效
+func Logger() *slog.Logger {
+	return base
+}
+
+// SetLogger 使用自定义的记录器输出框架日志，此时级别和格式由该记录器决定，传入 nil 恢复默认的记录器
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = newLogger(os.Stderr, "text")
+	}
+	current.Store(l)
+}
+
+// Configure 设置默认记录器的输出位置、级别（debug、info、warn、error）和格式（text 或 json）
+func Configure(w io.Writer, levelName, format string) error {
+	if err := SetLevel(levelName); err != nil {
+		return err
+	}
+	switch strings.ToLower(format) {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("无效的日志格式 %q，可选 text、json", format)
+	}
+	current.Store(newLogger(w, format))
+	return nil
+}
+
+// SetLevel 设置默认记录器的级别，立即生效
+func SetLevel(name string) error {
+	l, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+// ParseLevel 解析日志级别，空字符串表示 info
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("无效的日志级别 %q，可选 debug、info、warn、error", name)
+}
+
+// newLogger 创建使用全局级别的记录器
+func newLogger(w io.Writer, format string) *slog.Logger {
+	options := &slog.HandlerOptions{Level: level}
+	if strings.ToLower(format) == "json" {
+		return slog.New(slog.NewJSONHandler(w, options))
+	}
+	return slog.New(slog.NewTextHandler(w, options))
+}
+
+// forwardHandler 把日志转发给当前的记录器，并重放派生时添加的字段和分组
+type forwardHandler struct {
+	derive []func(slog.Handler) slog.Handler
+}
+
+func (h *forwardHandler) target() slog.Handler {
+	target := current.Load().Handler()
+	for _, derive := range h.derive {
+		target = derive(target)
+	}
+	return target
+}
+
+func (h *forwardHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return current.Load().Handler().Enabled(ctx, l)
+}
+
+func (h *forwardHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.target().Handle(ctx, r)
+}
+
+func (h *forwardHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(target slog.Handler) slog.Handler { return target.WithAttrs(attrs) })
+}
+
+func (h *forwardHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(target slog.Handler) slog.Handler { return target.WithGroup(name) })
+}
+
+func (h *forwardHandler) with(derive func(slog.Handler) slog.Handler) slog.Handler {
+	next := &forwardHandler{derive: make([]func(slog.Handler) slog.Handler, 0, len(h.derive)+1)}
+	next.derive = append(append(next.derive, h.derive...), derive)
+	return next
+}
@@ -0,0 +1,33 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/iamlibie/milonra-go/logging"
+)
+
+func TestConfigure(t *testing.T) {
+	var out bytes.Buffer
+	if err := logging.Configure(&out, "warn", "json"); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+	defer logging.SetLogger(nil)
+	defer logging.SetLevel("info")
+	logging.Logger().Info("hidden")
+	logging.Logger().Warn("shown")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	var entry map[string]interface{}
+	if len(lines) != 1 || json.Unmarshal([]byte(lines[0]), &entry) != nil || entry["msg"] != "shown" {
+		t.Errorf("Expected only the warn log, got %q", out.String())
+	}
+	if err := logging.Configure(&out, "verbose", "json"); err == nil {
+		t.Error("Expected invalid level to be rejected")
+	}
+	if err := logging.Configure(&out, "info", "xml"); err == nil {
+		t.Error("Expected invalid format to be rejected")
+	}
+}
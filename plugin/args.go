@@ -0,0 +1,314 @@
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/iamlibie/milonra-go/event"
+)
+
+// ArgType 命令参数类型
+type ArgType int
+
+const (
+	ArgString   ArgType = iota // 单个词，含空格时可以用引号包裹，如 "hello world"
+	ArgInt                     // 整数
+	ArgDuration                // 时长，如 10m、1h30m，纯数字按秒计算
+	ArgUser                    // 用户，@某人 或直接填写QQ号
+	ArgText                    // 剩余的全部文本，只能作为最后一个参数
+	ArgBool                    // 开关，只用于选项，出现即为 true
+)
+
+// String 返回参数类型在用法说明中的名称
+func (t ArgType) String() string {
+	switch t {
+	case ArgInt:
+		return "整数"
+	case ArgDuration:
+		return "时长"
+	case ArgUser:
+		return "@用户"
+	case ArgText:
+		return "文本"
+	case ArgBool:
+		return "开关"
+	}
+	return "字符串"
+}
+
+// ArgSpec 位置参数定义
+type ArgSpec struct {
+	Name     string  // 参数名，通过 Args 的方法按名称取值
+	Type     ArgType // 参数类型
+	Optional bool    // 是否可选，可选参数必须位于必选参数之后
+	Default  string  // 可选参数省略时的默认值，按 Type 解析
+	Help     string  // 参数说明，显示在用法中
+}
+
+// FlagSpec 选项定义，使用 --name value、--name=value 或 -s value 的形式传入
+type FlagSpec struct {
+	Name    string  // 长选项名，如 reason 对应 --reason
+	Short   string  // 短选项名（可选），如 r 对应 -r
+	Type    ArgType // 选项值的类型，ArgBool 表示不需要值的开关
+	Default string  // 未传入时的默认值，按 Type 解析
+	Help    string  // 选项说明
+}
+
+// Args 解析后的命令参数
+type Args struct {
+	Command string                 // 实际使用的命令名（可能是别名）
+	Match   *Match                 // 命令的匹配结果
+	values  map[string]interface{} // 参数名和选项名对应的值
+}
+
+// Has 参数或选项是否有值（传入了或设置了默认值）
+func (a *Args) Has(name string) bool {
+	_, ok := a.values[name]
+	return ok
+}
+
+// String 返回字符串或文本参数
+func (a *Args) String(name string) string {
+	v, _ := a.values[name].(string)
+	return v
+}
+
+// Int 返回整数参数
+func (a *Args) Int(name string) int64 {
+	v, _ := a.values[name].(int64)
+	return v
+}
+
+// Duration 返回时长参数
+func (a *Args) Duration(name string) time.Duration {
+	v, _ := a.values[name].(time.Duration)
+	return v
+}
+
+// User 返回用户参数的QQ号
+func (a *Args) User(name string) int64 {
+	v, _ := a.values[name].(userID)
+	return int64(v)
+}
+
+// Bool 返回开关选项
+func (a *Args) Bool(name string) bool {
+	v, _ := a.values[name].(bool)
+	return v
+}
+
+// userID 用户参数的值，与整数参数区分
+type userID int64
+
+// token 命令中的一个词
+type token struct {
+	text   string
+	user   int64 // @ 消息段对应的QQ号
+	isUser bool
+	quoted bool
+}
+
+// tokenize 将消息切分为词，支持引号包裹的字符串，@ 消息段单独成词
+func tokenize(e *event.MessageEvent) []token {
+	segments := e.Segments
+	if len(segments) == 0 {
+		segments = []event.MessageSegment{{Type: "text", Data: map[string]interface{}{"text": e.Message}}}
+	}
+
+	var tokens []token
+	for _, seg := range segments {
+		switch seg.Type {
+		case "text":
+			text, _ := seg.Data["text"].(string)
+			tokens = append(tokens, splitWords(text)...)
+		case "at":
+			var qq int64
+			switch v := seg.Data["qq"].(type) {
+			case string:
+				qq, _ = strconv.ParseInt(v, 10, 64)
+			case float64:
+				qq = int64(v)
+			}
+			if qq != 0 {
+				tokens = append(tokens, token{text: strconv.FormatInt(qq, 10), user: qq, isUser: true})
+			}
+		}
+	}
+	return tokens
+}
+
+// splitWords 按空白切分文本，引号内的空白不切分
+func splitWords(text string) []token {
+	var tokens []token
+	var sb strings.Builder
+	var closing rune
+	inWord, quoted := false, false
+
+	flush := func() {
+		if inWord {
+			tokens = append(tokens, token{text: sb.String(), quoted: quoted})
+		}
+		sb.Reset()
+		inWord, quoted = false, false
+	}
+
+	for _, r := range text {
+		switch {
+		case closing != 0:
+			if r == closing {
+				closing = 0
+				continue
+			}
+			sb.WriteRune(r)
+		case r == '"' || r == '\'' || r == '“':
+			closing = r
+			if r == '“' {
+				closing = '”'
+			}
+			inWord, quoted = true, true
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			inWord = true
+			sb.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// parseArgs 按命令定义解析消息中的参数
+func parseArgs(spec *CommandSpec, e *event.MessageEvent, m *Match) (*Args, error) {
+	args := &Args{Command: m.Command, Match: m, values: make(map[string]interface{})}
+
+	// 跳过@机器人等前导的@以及命令本身
+	tokens := tokenize(e)
+	for len(tokens) > 0 && tokens[0].isUser {
+		tokens = tokens[1:]
+	}
+	if len(tokens) > 0 {
+		tokens = tokens[1:]
+	}
+
+	var positional []token
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		flag, value, hasValue, ok := spec.lookupFlag(tok)
+		if !ok {
+			positional = append(positional, tok)
+			continue
+		}
+		if flag.Type == ArgBool {
+			args.values[flag.Name] = true
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(tokens) {
+				return nil, fmt.Errorf("选项 --%s 缺少值", flag.Name)
+			}
+			i++
+			value = tokens[i]
+		}
+		v, err := convertArg(flag.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("选项 --%s %v", flag.Name, err)
+		}
+		args.values[flag.Name] = v
+	}
+
+	for i, arg := range spec.Args {
+		if arg.Type == ArgText {
+			if i < len(positional) {
+				words := make([]string, 0, len(positional)-i)
+				for _, tok := range positional[i:] {
+					words = append(words, tok.text)
+				}
+				args.values[arg.Name] = strings.Join(words, " ")
+				positional = nil
+			} else if err := applyDefault(args, arg.Name, arg.Type, arg.Default, !arg.Optional); err != nil {
+				return nil, err
+			}
+			break
+		}
+		if i >= len(positional) {
+			if err := applyDefault(args, arg.Name, arg.Type, arg.Default, !arg.Optional); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		v, err := convertArg(arg.Type, positional[i])
+		if err != nil {
+			return nil, fmt.Errorf("参数 %s %v", arg.Name, err)
+		}
+		args.values[arg.Name] = v
+	}
+	if len(positional) > len(spec.Args) {
+		return nil, fmt.Errorf("多余的参数: %s", positional[len(spec.Args)].text)
+	}
+
+	for _, flag := range spec.Flags {
+		if _, ok := args.values[flag.Name]; ok {
+			continue
+		}
+		if err := applyDefault(args, flag.Name, flag.Type, flag.Default, false); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+// applyDefault 为省略的参数设置默认值，required 为 true 时返回缺少参数的错误
+func applyDefault(args *Args, name string, typ ArgType, def string, required bool) error {
+	if required {
+		return fmt.Errorf("缺少参数 %s", name)
+	}
+	if def == "" {
+		return nil
+	}
+	v, err := convertArg(typ, token{text: def})
+	if err != nil {
+		return fmt.Errorf("参数 %s 的默认值 %v", name, err)
+	}
+	args.values[name] = v
+	return nil
+}
+
+// convertArg 将词转换为参数类型对应的值
+func convertArg(typ ArgType, tok token) (interface{}, error) {
+	switch typ {
+	case ArgInt:
+		n, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("应为整数，实际为 %q", tok.text)
+		}
+		return n, nil
+	case ArgDuration:
+		if n, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+			return time.Duration(n) * time.Second, nil
+		}
+		d, err := time.ParseDuration(tok.text)
+		if err != nil {
+			return nil, fmt.Errorf("应为时长（如 30s、10m、1h），实际为 %q", tok.text)
+		}
+		return d, nil
+	case ArgUser:
+		if tok.isUser {
+			return userID(tok.user), nil
+		}
+		n, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("应为@用户或QQ号，实际为 %q", tok.text)
+		}
+		return userID(n), nil
+	case ArgBool:
+		b, err := strconv.ParseBool(tok.text)
+		if err != nil {
+			return nil, fmt.Errorf("应为 true 或 false，实际为 %q", tok.text)
+		}
+		return b, nil
+	}
+	return tok.text, nil
+}
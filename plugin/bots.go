@@ -0,0 +1,66 @@
+package plugin
+
+import (
+	"sort"
+	"sync"
+)
+
+// 当前在线的机器人账号，由连接的持有者（如 SDK）在连接建立和断开时维护
+var (
+	bots   = make(map[int64]Bot)
+	botsMu sync.RWMutex
+)
+
+// RegisterBot 记录 selfID 账号当前使用的 Bot，同一账号再次注册时替换原来的 Bot
+func RegisterBot(selfID int64, b Bot) {
+	botsMu.Lock()
+	defer botsMu.Unlock()
+	bots[selfID] = b
+}
+
+// UnregisterBot 移除 selfID 账号的 Bot，只有当前记录的正是 b 时才移除，避免移除已经重新连接的账号
+func UnregisterBot(selfID int64, b Bot) {
+	botsMu.Lock()
+	defer botsMu.Unlock()
+	if bots[selfID] == b {
+		delete(bots, selfID)
+	}
+}
+
+// GetBot 返回 selfID 账号当前使用的 Bot，账号不在线时返回 false
+func GetBot(selfID int64) (Bot, bool) {
+	botsMu.RLock()
+	defer botsMu.RUnlock()
+	b, ok := bots[selfID]
+	return b, ok
+}
+
+// Bots 返回所有在线的账号，按 selfID 排序
+func Bots() []Bot {
+	botsMu.RLock()
+	ids := make([]int64, 0, len(bots))
+	for id := range bots {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	result := make([]Bot, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, bots[id])
+	}
+	botsMu.RUnlock()
+	return result
+}
+
+// SwitchBot 返回用 selfID 账号发送消息的 Bot，并保留 current 绑定的上下文，账号不在线时返回 false
+//
+//	other, ok := plugin.SwitchBot(bot, 10002)
+//	if ok {
+//	    api.SendGroupMessage(other, groupID, "由另一个账号发送")
+//	}
+func SwitchBot(current Bot, selfID int64) (Bot, bool) {
+	b, ok := GetBot(selfID)
+	if !ok {
+		return nil, false
+	}
+	return WithContext(b, ContextOf(current)), true
+}
@@ -0,0 +1,221 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/iamlibie/milonra-go/event"
+)
+
+// SwitchPluginName 内置插件管理插件的名称，该插件始终启用
+const SwitchPluginName = "plugin_manager"
+
+// RegisterSwitchCommands 注册内置的插件管理命令：
+//
+//	/插件列表        查看插件在当前群（私聊中为自己）的启用状态
+//	/启用 <插件>     在当前群启用插件，私聊中只对自己生效
+//	/禁用 <插件>     在当前群禁用插件，私聊中只对自己生效
+//
+// 群聊中只有群主、管理员和超级用户可以修改开关，超级用户可以使用 --global 修改全局开关
+func RegisterSwitchCommands() {
+	SetAlwaysEnabled(SwitchPluginName)
+	RegisterCommands(SwitchPluginName, []CommandSpec{
+		{
+			Name:        "插件列表",
+			Aliases:     []string{"plugins"},
+			Description: "查看插件在当前会话的启用状态",
+			Handler:     listPluginsCommand,
+		},
+		{
+			Name:        "启用",
+			Aliases:     []string{"enable"},
+			Description: "在当前群启用插件（私聊中只对自己生效）",
+			Args:        []ArgSpec{{Name: "插件", Type: ArgString}},
+			Flags:       []FlagSpec{{Name: "global", Short: "g", Type: ArgBool, Help: "修改全局开关（仅超级用户）"}},
+			Handler:     switchCommand(true),
+		},
+		{
+			Name:        "禁用",
+			Aliases:     []string{"disable"},
+			Description: "在当前群禁用插件（私聊中只对自己生效）",
+			Args:        []ArgSpec{{Name: "插件", Type: ArgString}},
+			Flags:       []FlagSpec{{Name: "global", Short: "g", Type: ArgBool, Help: "修改全局开关（仅超级用户）"}},
+			Handler:     switchCommand(false),
+		},
+	})
+}
+
+// RolePluginName 内置角色管理插件的名称，该插件始终启用
+const RolePluginName = "role_manager"
+
+// RegisterRoleCommands 注册内置的角色管理命令：
+//
+//	/授权 <用户> <角色>     授予用户在当前群的角色
+//	/撤销授权 <用户> <角色> 撤销用户在当前群的角色
+//	/角色 [用户]            查看用户在当前群拥有的角色
+//
+// 授权和撤销需要群管理员权限，超级用户可以使用 --global 管理在所有群生效的全局角色
+func RegisterRoleCommands() {
+	SetAlwaysEnabled(RolePluginName)
+	globalFlag := []FlagSpec{{Name: "global", Short: "g", Type: ArgBool, Help: "全局角色（仅超级用户）"}}
+	roleArgs := []ArgSpec{{Name: "用户", Type: ArgUser}, {Name: "角色", Type: ArgString}}
+	RegisterCommands(RolePluginName, []CommandSpec{
+		{
+			Name:        "授权",
+			Aliases:     []string{"grant"},
+			Description: "授予用户在当前群的角色",
+			Args:        roleArgs,
+			Flags:       globalFlag,
+			Permission:  PermAdmin,
+			Handler:     roleCommand(true),
+		},
+		{
+			Name:        "撤销授权",
+			Aliases:     []string{"revoke"},
+			Description: "撤销用户在当前群的角色",
+			Args:        roleArgs,
+			Flags:       globalFlag,
+			Permission:  PermAdmin,
+			Handler:     roleCommand(false),
+		},
+		{
+			Name:        "角色",
+			Aliases:     []string{"roles"},
+			Description: "查看用户在当前群拥有的角色，默认为自己",
+			Args:        []ArgSpec{{Name: "用户", Type: ArgUser, Optional: true}},
+			Handler:     listRolesCommand,
+		},
+	})
+}
+
+// roleCommand 返回授予或撤销角色的命令处理函数
+func roleCommand(grant bool) CommandFunc {
+	return func(ctx context.Context, bot Bot, e *event.MessageEvent, args *Args) *Reply {
+		groupID := e.GroupID
+		if args.Bool("global") {
+			if !HasPermission(e, PermSuperuser) {
+				return DeniedReply(PermSuperuser, nil)
+			}
+			groupID = 0
+		}
+
+		userID, role := args.User("用户"), args.String("角色")
+		var err error
+		action := "授予"
+		if grant {
+			err = GrantRole(groupID, userID, role)
+		} else {
+			err, action = RevokeRole(groupID, userID, role), "撤销"
+		}
+		if err != nil {
+			return NewReply(fmt.Sprintf("❌ 保存角色失败: %v", err))
+		}
+		return NewReply(fmt.Sprintf("✅ 已%s用户 %d 的角色 %s", action, userID, role))
+	}
+}
+
+// listRolesCommand 列出用户在当前群拥有的角色
+func listRolesCommand(ctx context.Context, bot Bot, e *event.MessageEvent, args *Args) *Reply {
+	userID := e.UserID
+	if args.Has("用户") {
+		userID = args.User("用户")
+	}
+
+	names := UserRoles(e.GroupID, userID)
+	if IsSuperuser(userID) {
+		names = append([]string{"超级用户"}, names...)
+	}
+	if len(names) == 0 {
+		return NewReply(fmt.Sprintf("用户 %d 没有任何角色", userID))
+	}
+	return NewReply(fmt.Sprintf("用户 %d 的角色：%s", userID, strings.Join(names, "、")))
+}
+
+// listPluginsCommand 列出所有插件在当前会话的启用状态
+func listPluginsCommand(ctx context.Context, bot Bot, e *event.MessageEvent, args *Args) *Reply {
+	var sb strings.Builder
+	sb.WriteString("🔌 插件列表")
+	for _, name := range pluginNames() {
+		status := "✅"
+		if !IsEnabled(name, e.GroupID, e.UserID) {
+			status = "❌"
+		}
+		fmt.Fprintf(&sb, "\n%s %s", status, name)
+	}
+	return NewReply(sb.String())
+}
+
+// switchCommand 返回启用或禁用插件的命令处理函数
+func switchCommand(enabled bool) CommandFunc {
+	return func(ctx context.Context, bot Bot, e *event.MessageEvent, args *Args) *Reply {
+		name := args.String("插件")
+		if !pluginExists(name) {
+			return NewReply(fmt.Sprintf("❌ 插件不存在: %s", name))
+		}
+		if isAlwaysEnabled(name) {
+			return NewReply(fmt.Sprintf("❌ 插件 %s 始终启用，不能修改开关", name))
+		}
+
+		action := "启用"
+		if !enabled {
+			action = "禁用"
+		}
+
+		var err error
+		var scope string
+		switch {
+		case args.Bool("global"):
+			if !HasPermission(e, PermSuperuser) {
+				return DeniedReply(PermSuperuser, nil)
+			}
+			err, scope = SetEnabled(name, enabled), "全局"
+		case e.IsGroup():
+			if !HasPermission(e, PermAdmin) {
+				return DeniedReply(PermAdmin, nil)
+			}
+			err, scope = SetGroupEnabled(e.GroupID, name, enabled), "在本群"
+		default:
+			err, scope = SetUserEnabled(e.UserID, name, enabled), "为您"
+		}
+		if err != nil {
+			return NewReply(fmt.Sprintf("❌ 保存插件开关失败: %v", err))
+		}
+		return NewReply(fmt.Sprintf("✅ 已%s%s插件 %s", scope, action, name))
+	}
+}
+
+// pluginNames 返回所有已注册的消息、通知、请求和元事件插件的名称
+func pluginNames() []string {
+	seen := make(map[string]bool)
+	for _, p := range List() {
+		seen[p.Name] = true
+	}
+	for name := range GetNoticePlugins() {
+		seen[name] = true
+	}
+	for _, p := range GetRequestPlugins() {
+		seen[p.Name] = true
+	}
+	for name := range GetMetaPlugins() {
+		seen[name] = true
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pluginExists 插件是否已注册
+func pluginExists(name string) bool {
+	for _, n := range pluginNames() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,265 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/ratelimit"
+)
+
+// CommandFunc 命令处理函数类型，args 为按 CommandSpec 解析并校验后的参数
+// 返回 nil 表示不回复
+type CommandFunc func(ctx context.Context, bot Bot, event *event.MessageEvent, args *Args) *Reply
+
+// CommandSpec 命令定义
+type CommandSpec struct {
+	Name        string        // 命令名
+	Aliases     []string      // 别名
+	Description string        // 命令说明，显示在帮助中
+	Args        []ArgSpec     // 位置参数
+	Flags       []FlagSpec    // 选项
+	Permission  Permission    // 执行命令要求的权限等级
+	Cooldown    time.Duration // 命令的冷却时间，每个用户每隔 Cooldown 最多执行一次，0 表示不限制
+	Handler     CommandFunc   // 处理函数
+}
+
+// Usage 返回命令的用法，如 "/ban <用户:@用户> [时长:时长] [--reason 文本]"
+func (c *CommandSpec) Usage(prefix string) string {
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	sb.WriteString(c.Name)
+	for _, arg := range c.Args {
+		left, right := "<", ">"
+		if arg.Optional {
+			left, right = "[", "]"
+		}
+		name := arg.Name
+		if arg.Type == ArgText {
+			name += "..."
+		}
+		fmt.Fprintf(&sb, " %s%s:%s%s", left, name, arg.Type, right)
+	}
+	for _, flag := range c.Flags {
+		if flag.Type == ArgBool {
+			fmt.Fprintf(&sb, " [--%s]", flag.Name)
+			continue
+		}
+		fmt.Fprintf(&sb, " [--%s %s]", flag.Name, flag.Type)
+	}
+	return sb.String()
+}
+
+// Help 返回命令的详细帮助，包括用法、别名和参数说明
+func (c *CommandSpec) Help(prefix string) string {
+	var sb strings.Builder
+	sb.WriteString("用法: " + c.Usage(prefix))
+	if c.Description != "" {
+		sb.WriteString("\n" + c.Description)
+	}
+	if len(c.Aliases) > 0 {
+		sb.WriteString("\n别名: " + strings.Join(c.Aliases, ", "))
+	}
+	for _, arg := range c.Args {
+		if arg.Help != "" {
+			fmt.Fprintf(&sb, "\n  %s: %s", arg.Name, arg.Help)
+		}
+	}
+	for _, flag := range c.Flags {
+		if flag.Help == "" {
+			continue
+		}
+		if flag.Short != "" {
+			fmt.Fprintf(&sb, "\n  --%s, -%s: %s", flag.Name, flag.Short, flag.Help)
+		} else {
+			fmt.Fprintf(&sb, "\n  --%s: %s", flag.Name, flag.Help)
+		}
+	}
+	return sb.String()
+}
+
+// names 返回命令名和所有别名
+func (c *CommandSpec) names() []string {
+	return append([]string{c.Name}, c.Aliases...)
+}
+
+// lookupFlag 检查词是否为该命令的选项，返回选项定义和 --name=value 形式中的值
+func (c *CommandSpec) lookupFlag(tok token) (flag FlagSpec, value token, hasValue, ok bool) {
+	if tok.quoted || tok.isUser || len(tok.text) < 2 || tok.text[0] != '-' {
+		return FlagSpec{}, token{}, false, false
+	}
+
+	name := strings.TrimPrefix(tok.text, "-")
+	long := strings.HasPrefix(name, "-")
+	name = strings.TrimPrefix(name, "-")
+	if i := strings.Index(name, "="); i >= 0 {
+		value = token{text: name[i+1:]}
+		name, hasValue = name[:i], true
+	}
+
+	for _, f := range c.Flags {
+		if (long && f.Name == name) || (!long && f.Short != "" && f.Short == name) {
+			return f, value, hasValue, true
+		}
+	}
+	return FlagSpec{}, token{}, false, false
+}
+
+// RegisterCommands 注册一个由若干命令组成的插件
+//
+// 命令参数会按 CommandSpec 自动解析和校验，参数错误时自动回复错误原因和用法；
+// 所有通过 RegisterCommands 注册的命令会出现在自动生成的 /help 中
+//
+//	plugin.RegisterCommands("admin", []plugin.CommandSpec{{
+//	    Name:        "ban",
+//	    Description: "禁言群成员",
+//	    Args: []plugin.ArgSpec{
+//	        {Name: "用户", Type: plugin.ArgUser},
+//	        {Name: "时长", Type: plugin.ArgDuration, Optional: true, Default: "10m"},
+//	    },
+//	    Handler: BanCommand,
+//	}}, plugin.GroupOnly())
+func RegisterCommands(name string, commands []CommandSpec, opts ...Option) {
+	registerCommands(name, commands, opts)
+	helpOnce.Do(registerHelp)
+}
+
+// registerCommands 注册命令插件，不触发帮助插件的注册
+func registerCommands(name string, commands []CommandSpec, opts []Option) {
+	byName := make(map[string]*CommandSpec)
+	cooldowns := make(map[*CommandSpec]*ratelimit.Limiter)
+	var names []string
+	for i := range commands {
+		cmd := &commands[i]
+		for _, n := range cmd.names() {
+			byName[n] = cmd
+			names = append(names, n)
+		}
+		if cmd.Cooldown > 0 {
+			cooldowns[cmd] = ratelimit.Every(cmd.Cooldown)
+		}
+	}
+
+	opts = append([]Option{Command(names...), func(p *Plugin) { p.CommandSpecs = commands }}, opts...)
+	add(name, func(ctx context.Context, bot Bot, e *event.MessageEvent) *Reply {
+		m := MatchOf(ctx)
+		cmd, ok := byName[m.Command]
+		if !ok {
+			return nil
+		}
+		if !HasPermission(e, cmd.Permission) {
+			return DeniedReply(cmd.Permission, nil)
+		}
+		args, err := parseArgs(cmd, e, m)
+		if err != nil {
+			// 参数错误时自动回复原因和用法
+			return NewReply(fmt.Sprintf("❌ %v\n用法: %s", err, cmd.Usage(m.Prefix))).Quoted()
+		}
+
+		// 命令冷却，超级用户不受限制
+		cooldown := cooldowns[cmd]
+		if IsSuperuser(e.UserID) {
+			cooldown = nil
+		}
+		key := fmt.Sprint(e.UserID)
+		// 执行前进入冷却，避免并发的调用同时通过检查；命令没有处理时取消冷却
+		if cooldown != nil {
+			if retryAfter, ok := cooldown.Allow(key); !ok {
+				if msg := ratelimit.Reject(e.UserID, retryAfter); msg != "" {
+					return NewReply(msg).Quoted()
+				}
+				return NewReply()
+			}
+		}
+
+		reply := cmd.Handler(ctx, bot, e, args)
+		if reply == nil && cooldown != nil {
+			cooldown.Refund(key)
+		}
+		return reply
+	}, opts)
+	logRegistered(name, "command")
+}
+
+// helpOnce 第一次注册命令时自动注册帮助插件
+var helpOnce sync.Once
+
+// HelpPluginName 自动注册的帮助插件名，已存在同名插件时不会注册
+const HelpPluginName = "help"
+
+// registerHelp 注册 /help 命令，已存在同名插件时跳过
+// 帮助插件始终启用，配置了 EnabledPlugins 时也不会被禁用
+func registerHelp() {
+	for _, p := range List() {
+		if p.Name == HelpPluginName {
+			return
+		}
+	}
+	registerCommands(HelpPluginName, []CommandSpec{{
+		Name:        "help",
+		Aliases:     []string{"帮助"},
+		Description: "查看命令列表或某个命令的用法",
+		Args:        []ArgSpec{{Name: "命令", Type: ArgString, Optional: true}},
+		Handler: func(ctx context.Context, bot Bot, e *event.MessageEvent, args *Args) *Reply {
+			if name := args.String("命令"); name != "" {
+				return NewReply(CommandHelp(name))
+			}
+			return NewReply(HelpText())
+		},
+	}}, nil)
+	SetAlwaysEnabled(HelpPluginName)
+}
+
+// HelpText 生成所有通过 RegisterCommands 注册的命令的帮助，按插件分组
+func HelpText() string {
+	var sb strings.Builder
+	sb.WriteString("📖 命令列表")
+	for _, p := range List() {
+		if len(p.CommandSpecs) == 0 || p.Name == HelpPluginName {
+			continue
+		}
+		prefix := p.displayPrefix()
+		fmt.Fprintf(&sb, "\n[%s]", p.Name)
+		for _, cmd := range p.CommandSpecs {
+			sb.WriteString("\n  " + cmd.Usage(prefix))
+			if cmd.Description != "" {
+				sb.WriteString(" - " + cmd.Description)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// CommandHelp 返回某个命令的详细帮助，name 可以是命令名、别名或插件名
+func CommandHelp(name string) string {
+	name = strings.TrimLeft(name, "/#")
+	for _, p := range List() {
+		if p.Name == name && len(p.CommandSpecs) > 0 {
+			lines := make([]string, 0, len(p.CommandSpecs))
+			for i := range p.CommandSpecs {
+				lines = append(lines, p.CommandSpecs[i].Help(p.displayPrefix()))
+			}
+			return fmt.Sprintf("[%s]\n%s", p.Name, strings.Join(lines, "\n\n"))
+		}
+		for i := range p.CommandSpecs {
+			cmd := &p.CommandSpecs[i]
+			for _, n := range cmd.names() {
+				if n == name {
+					return cmd.Help(p.displayPrefix())
+				}
+			}
+		}
+	}
+	return fmt.Sprintf("❌ 未找到命令: %s", name)
+}
+
+// displayPrefix 返回帮助中显示的命令前缀
+func (p *Plugin) displayPrefix() string {
+	if prefixes := p.commandPrefixes(); len(prefixes) > 0 {
+		return prefixes[0]
+	}
+	return ""
+}
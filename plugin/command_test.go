@@ -0,0 +1,176 @@
+package plugin_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+// Test the built-in help plugin stays enabled when EnabledPlugins is set
+func TestHelpAlwaysEnabled(t *testing.T) {
+	plugin.RegisterCommands("help_test", []plugin.CommandSpec{{
+		Name: "ping",
+		Handler: func(ctx context.Context, bot plugin.Bot, e *event.MessageEvent, args *plugin.Args) *plugin.Reply {
+			return plugin.NewReply("pong")
+		},
+	}})
+	t.Cleanup(func() { plugin.Unregister("help_test") })
+
+	plugin.SetEnabledPlugins([]string{"help_test"})
+	t.Cleanup(func() { plugin.SetEnabledPlugins(nil) })
+
+	if !plugin.IsEnabled(plugin.HelpPluginName, 1001, 7) {
+		t.Error("Expected the help plugin to stay enabled with EnabledPlugins set")
+	}
+	if plugin.IsEnabled("other", 1001, 7) {
+		t.Error("Expected plugins outside EnabledPlugins to be disabled")
+	}
+}
+
+func TestCommandArgs(t *testing.T) {
+	type banArgs struct {
+		user     int64
+		duration time.Duration
+		reason   string
+		silent   bool
+	}
+	got := make(chan banArgs, 1)
+	plugin.RegisterCommands("command_test", []plugin.CommandSpec{{
+		Name:        "ban",
+		Aliases:     []string{"禁言"},
+		Description: "禁言群成员",
+		Args: []plugin.ArgSpec{
+			{Name: "user", Type: plugin.ArgUser},
+			{Name: "duration", Type: plugin.ArgDuration, Optional: true, Default: "10m"},
+		},
+		Flags: []plugin.FlagSpec{
+			{Name: "reason", Short: "r", Type: plugin.ArgString},
+			{Name: "silent", Type: plugin.ArgBool},
+		},
+		Handler: func(ctx context.Context, bot plugin.Bot, e *event.MessageEvent, args *plugin.Args) *plugin.Reply {
+			got <- banArgs{args.User("user"), args.Duration("duration"), args.String("reason"), args.Bool("silent")}
+			return plugin.NewReply()
+		},
+	}})
+	defer plugin.Unregister("command_test")
+
+	var cmd *plugin.Plugin
+	for _, p := range plugin.List() {
+		if p.Name == "command_test" {
+			cmd = p
+		}
+	}
+	if cmd == nil {
+		t.Fatal("Expected command_test to be registered")
+	}
+	run := func(e *event.MessageEvent) *plugin.Reply {
+		m, ok := cmd.Match(e)
+		if !ok {
+			t.Fatalf("Expected %q to match", e.Message)
+		}
+		return cmd.Handler(plugin.WithMatch(context.Background(), m), nil, e)
+	}
+
+	run(&event.MessageEvent{
+		Message: "/ban [CQ:at,qq=10001] 1h --reason \"刷屏 广告\" --silent",
+		Segments: []event.MessageSegment{
+			{Type: "text", Data: map[string]interface{}{"text": "/ban "}},
+			{Type: "at", Data: map[string]interface{}{"qq": "10001"}},
+			{Type: "text", Data: map[string]interface{}{"text": " 1h --reason \"刷屏 广告\" --silent"}},
+		},
+	})
+	if args := <-got; args != (banArgs{10001, time.Hour, "刷屏 广告", true}) {
+		t.Errorf("Unexpected args: %+v", args)
+	}
+
+	run(&event.MessageEvent{Message: "#禁言 10002 -r spam"})
+	if args := <-got; args != (banArgs{10002, 10 * time.Minute, "spam", false}) {
+		t.Errorf("Unexpected args with defaults: %+v", args)
+	}
+
+	reply := run(&event.MessageEvent{Message: "/ban 10003 soon"})
+	if reply == nil || len(reply.Messages) != 1 || !strings.Contains(reply.Messages[0].(string), "用法: /ban") {
+		t.Errorf("Expected usage error reply, got %+v", reply)
+	}
+	select {
+	case args := <-got:
+		t.Errorf("Expected handler not to be called on bad input, got %+v", args)
+	default:
+	}
+
+	if help := plugin.HelpText(); !strings.Contains(help, "[command_test]") || !strings.Contains(help, "禁言群成员") {
+		t.Errorf("Expected help to list command_test, got %q", help)
+	}
+}
+
+// Test command cooldowns ignore invalid arguments and hold under concurrent calls
+func TestCommandCooldown(t *testing.T) {
+	// 参数错误不消耗冷却，执行成功后冷却期内再次调用会被拒绝
+	calls := 0
+	plugin.RegisterCommands("cooldown_test", []plugin.CommandSpec{{
+		Name:     "cooldown_test",
+		Args:     []plugin.ArgSpec{{Name: "n", Type: plugin.ArgInt}},
+		Cooldown: time.Minute,
+		Handler: func(ctx context.Context, bot plugin.Bot, e *event.MessageEvent, args *plugin.Args) *plugin.Reply {
+			calls++
+			return plugin.NewReply("ok")
+		},
+	}})
+	defer plugin.Unregister("cooldown_test")
+	var cmd *plugin.Plugin
+	for _, p := range plugin.List() {
+		if p.Name == "cooldown_test" {
+			cmd = p
+		}
+	}
+	run := func(message string) *plugin.Reply {
+		e := &event.MessageEvent{UserID: 6, Message: message}
+		m, _ := cmd.Match(e)
+		return cmd.Handler(plugin.WithMatch(context.Background(), m), nil, e)
+	}
+	run("/cooldown_test abc")
+	run("/cooldown_test 1")
+	reply := run("/cooldown_test 2")
+	if calls != 1 {
+		t.Errorf("Expected handler to run once, got %d", calls)
+	}
+	if reply == nil || len(reply.Messages) != 1 || !strings.Contains(reply.Messages[0].(string), "秒后再试") {
+		t.Errorf("Expected cooldown reply, got %+v", reply)
+	}
+
+	// 并发调用同样受冷却限制
+	var concurrentCalls atomic.Int32
+	plugin.RegisterCommands("cooldown_concurrent", []plugin.CommandSpec{{
+		Name:     "cooldown_concurrent",
+		Cooldown: time.Minute,
+		Handler: func(ctx context.Context, bot plugin.Bot, e *event.MessageEvent, args *plugin.Args) *plugin.Reply {
+			concurrentCalls.Add(1)
+			time.Sleep(20 * time.Millisecond)
+			return plugin.NewReply("ok")
+		},
+	}})
+	defer plugin.Unregister("cooldown_concurrent")
+	for _, p := range plugin.List() {
+		if p.Name == "cooldown_concurrent" {
+			cmd = p
+		}
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run("/cooldown_concurrent")
+		}()
+	}
+	wg.Wait()
+	if n := concurrentCalls.Load(); n != 1 {
+		t.Errorf("Expected concurrent commands to run once, got %d", n)
+	}
+}
@@ -0,0 +1,44 @@
+package plugin
+
+import "context"
+
+// contextBot 绑定了上下文的 Bot
+type contextBot struct {
+	Bot
+	ctx context.Context
+}
+
+// Context 返回绑定的上下文
+func (cb *contextBot) Context() context.Context {
+	return cb.ctx
+}
+
+// Unwrap 返回被包装的 Bot
+func (cb *contextBot) Unwrap() Bot {
+	return cb.Bot
+}
+
+// WithContext 返回绑定了 ctx 的 Bot，通过它发起的 API 调用会在 ctx 取消时立即返回
+func WithContext(b Bot, ctx context.Context) Bot {
+	return &contextBot{Bot: b, ctx: ctx}
+}
+
+// ContextOf 返回 Bot 绑定的上下文，没有绑定时返回 context.Background()
+//
+// 框架调用插件时传入的 Bot 都绑定了上下文，会在插件超时、连接断开或服务停止时取消，
+// 返回字符串的旧式插件和通知、请求插件可以通过它感知取消：
+//
+//	ctx := plugin.ContextOf(bot)
+//	select {
+//	case <-ctx.Done():
+//	    return ""
+//	case <-time.After(10 * time.Second):
+//	}
+func ContextOf(b Bot) context.Context {
+	if cb, ok := b.(interface{ Context() context.Context }); ok {
+		if ctx := cb.Context(); ctx != nil {
+			return ctx
+		}
+	}
+	return context.Background()
+}
@@ -0,0 +1,38 @@
+package plugin
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/iamlibie/milonra-go/logging"
+)
+
+type loggerKey struct{}
+
+// Logger 返回插件专用的日志记录器，输出的日志带有 plugin 字段
+func Logger(name string) *slog.Logger {
+	return logging.Logger().With(logging.KeyPlugin, name)
+}
+
+// WithLogger 返回携带日志记录器的 ctx，框架调用插件时会放入带有插件和事件字段的记录器
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// LoggerOf 返回插件处理当前事件时使用的日志记录器，带有 plugin、self_id、group_id、user_id 字段，
+// ctx 不是框架调用插件时传入的上下文时返回框架的记录器
+//
+//	plugin.LoggerOf(ctx).Info("查询天气", "city", city)
+//
+// 返回字符串的旧式插件可以通过 plugin.LoggerOf(plugin.ContextOf(bot)) 获取
+func LoggerOf(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return logging.Logger()
+}
+
+// logRegistered 记录插件注册，kind 为插件类型
+func logRegistered(name, kind string) {
+	logging.Logger().Info("插件已注册", logging.KeyPlugin, name, "type", kind)
+}
@@ -1,7 +1,9 @@
 package plugin
 
 import (
-	"fmt"
+	"context"
+	"sync"
+	"time"
 
 	"github.com/iamlibie/milonra-go/event"
 )
@@ -15,15 +17,205 @@ type Bot interface {
 // PluginFunc 插件函数类型：输入bot实例和消息事件，输出回复
 type PluginFunc func(bot Bot, event *event.MessageEvent) string
 
-// 存储所有注册的插件
+// 存储所有注册的返回字符串的插件
 var plugins = make(map[string]PluginFunc)
 
-// Register 注册一个插件
-func Register(name string, fn PluginFunc) {
+// Register 注册一个插件，返回非空字符串表示处理了该消息
+func Register(name string, fn PluginFunc, opts ...Option) {
+	registryMu.Lock()
 	plugins[name] = fn
-	fmt.Printf("插件已注册: %s\n", name)
+	registryMu.Unlock()
+
+	add(name, func(ctx context.Context, bot Bot, e *event.MessageEvent) *Reply {
+		reply := fn(bot, e)
+		if reply == "" {
+			return nil
+		}
+		return NewReply(reply)
+	}, opts)
+	logRegistered(name, "message")
 }
 
+// GetPlugins 返回通过 Register 注册的插件，全部消息插件请使用 List
 func GetPlugins() map[string]PluginFunc {
-	return plugins
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	result := make(map[string]PluginFunc, len(plugins))
+	for name, fn := range plugins {
+		result[name] = fn
+	}
+	return result
+}
+
+// Reply 插件的回复内容，可以包含多条消息
+type Reply struct {
+	Messages []interface{} // 依次发送的消息，每条可以是 string、*api.Message 或 []api.MessageSegment
+	Quote    bool          // 是否引用触发消息（仅添加在第一条消息上）
+}
+
+// NewReply 创建回复，messages 会按顺序依次发送
+// 不传入任何消息时表示处理了事件但不回复，可用于阻止低优先级插件处理
+func NewReply(messages ...interface{}) *Reply {
+	return &Reply{Messages: messages}
+}
+
+// Quoted 设置回复时引用触发消息
+func (r *Reply) Quoted() *Reply {
+	r.Quote = true
+	return r
+}
+
+// ReplyFunc 富消息插件函数类型：输入bot实例和消息事件，输出回复
+// 返回 nil 表示不处理该消息
+type ReplyFunc func(bot Bot, event *event.MessageEvent) *Reply
+
+// RegisterReply 注册一个富消息插件，插件可以回复图片、@、引用回复或多条消息
+func RegisterReply(name string, fn ReplyFunc, opts ...Option) {
+	add(name, func(ctx context.Context, bot Bot, e *event.MessageEvent) *Reply {
+		return fn(bot, e)
+	}, opts)
+	logRegistered(name, "message")
+}
+
+// HandlerFunc 支持上下文的插件函数类型：ctx 会在插件超时、连接断开或服务停止时取消
+// 返回 nil 表示不处理该消息
+type HandlerFunc func(ctx context.Context, bot Bot, event *event.MessageEvent) *Reply
+
+// RegisterHandler 注册一个支持上下文的插件，适合执行耗时操作、需要在停止时及时退出的插件
+func RegisterHandler(name string, fn HandlerFunc, opts ...Option) {
+	add(name, fn, opts)
+	logRegistered(name, "message")
+}
+
+// 各插件单独设置的超时时间
+var (
+	timeouts   = make(map[string]time.Duration)
+	timeoutsMu sync.RWMutex
+)
+
+// SetTimeout 设置指定插件单次调用的超时时间，覆盖全局的插件超时设置，0 表示不限制
+func SetTimeout(name string, timeout time.Duration) {
+	timeoutsMu.Lock()
+	defer timeoutsMu.Unlock()
+	timeouts[name] = timeout
+}
+
+// GetTimeout 获取插件单独设置的超时时间，ok 为 false 表示未单独设置
+func GetTimeout(name string) (timeout time.Duration, ok bool) {
+	timeoutsMu.RLock()
+	defer timeoutsMu.RUnlock()
+	timeout, ok = timeouts[name]
+	return timeout, ok
+}
+
+// NoticeFunc 通知插件函数类型：输入bot实例和通知事件，输出回复
+// 返回非空字符串时，会回复到通知所在的群（GroupID 不为0）或私聊（UserID）
+type NoticeFunc func(bot Bot, event event.Notice) string
+
+// 存储所有注册的通知插件
+var noticePlugins = make(map[string]NoticeFunc)
+
+// RegisterNotice 注册一个通知插件
+func RegisterNotice(name string, fn NoticeFunc) {
+	registryMu.Lock()
+	noticePlugins[name] = fn
+	registryMu.Unlock()
+	logRegistered(name, "notice")
+}
+
+// GetNoticePlugins 返回已注册的通知插件的副本，可以与注册并发调用
+func GetNoticePlugins() map[string]NoticeFunc {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	result := make(map[string]NoticeFunc, len(noticePlugins))
+	for name, fn := range noticePlugins {
+		result[name] = fn
+	}
+	return result
+}
+
+// RequestReply 请求插件的处理结果，会被自动转换为 set_friend_add_request 或 set_group_add_request 调用
+type RequestReply struct {
+	Approve bool   // 是否同意请求
+	Remark  string // 同意加好友请求后设置的备注（仅好友请求有效）
+	Reason  string // 拒绝加群请求的理由（仅加群请求有效）
+}
+
+// Approve 同意请求，remark 为好友备注（加群请求时忽略）
+func Approve(remark string) *RequestReply {
+	return &RequestReply{Approve: true, Remark: remark}
+}
+
+// Reject 拒绝请求，reason 为拒绝理由（好友请求时忽略）
+func Reject(reason string) *RequestReply {
+	return &RequestReply{Approve: false, Reason: reason}
+}
+
+// RequestFunc 请求插件函数类型：输入bot实例和请求事件，输出处理结果
+// 返回 nil 表示不处理此请求，交由其他请求插件决定
+type RequestFunc func(bot Bot, event *event.RequestEvent) *RequestReply
+
+// RequestPlugin 已注册的请求插件
+type RequestPlugin struct {
+	Name string
+	Func RequestFunc
+}
+
+// 存储所有注册的请求插件，按注册顺序排列
+var requestPlugins []RequestPlugin
+
+// RegisterRequest 注册一个请求插件，重复注册同名插件时替换原有插件并保持其顺序
+func RegisterRequest(name string, fn RequestFunc) {
+	registryMu.Lock()
+	replaced := false
+	for i := range requestPlugins {
+		if requestPlugins[i].Name == name {
+			requestPlugins[i].Func = fn
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		requestPlugins = append(requestPlugins, RequestPlugin{Name: name, Func: fn})
+	}
+	registryMu.Unlock()
+	logRegistered(name, "request")
+}
+
+// GetRequestPlugins 返回已注册的请求插件的副本，按注册顺序排列
+func GetRequestPlugins() []RequestPlugin {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	result := make([]RequestPlugin, len(requestPlugins))
+	copy(result, requestPlugins)
+	return result
+}
+
+// MetaFunc 元事件插件函数类型：输入bot实例和元事件（生命周期、心跳）
+type MetaFunc func(bot Bot, event event.Meta)
+
+// 存储所有注册的元事件插件
+var metaPlugins = make(map[string]MetaFunc)
+
+// RegisterMeta 注册一个元事件插件
+func RegisterMeta(name string, fn MetaFunc) {
+	registryMu.Lock()
+	metaPlugins[name] = fn
+	registryMu.Unlock()
+	logRegistered(name, "meta")
+}
+
+// GetMetaPlugins 返回已注册的元事件插件的副本，可以与注册并发调用
+func GetMetaPlugins() map[string]MetaFunc {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	result := make(map[string]MetaFunc, len(metaPlugins))
+	for name, fn := range metaPlugins {
+		result[name] = fn
+	}
+	return result
 }
@@ -0,0 +1,210 @@
+package plugin
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/iamlibie/milonra-go/event"
+)
+
+// Match 消息匹配的结果，由匹配规则填充
+type Match struct {
+	Text    string            // 参与匹配的纯文本（已去除首尾空白）
+	Prefix  string            // 命令前缀，如 "/"
+	Command string            // 实际使用的命令名（可能是别名）
+	Args    string            // 命令之后的参数文本（已去除首尾空白）
+	Fields  []string          // Args 按空白分割后的参数
+	Groups  []string          // 正则匹配的捕获组，Groups[0] 为整个匹配
+	Named   map[string]string // 正则中命名捕获组的值
+	Keyword string            // 匹配到的关键词
+}
+
+// Rule 匹配规则，返回 false 表示消息不匹配，匹配时可以向 m 中写入解析结果
+type Rule func(e *event.MessageEvent, m *Match) bool
+
+// MatchFunc 带匹配结果的插件函数类型，只有消息满足所有规则时才会被调用
+// 返回 nil 表示不处理该消息
+type MatchFunc func(ctx context.Context, bot Bot, event *event.MessageEvent, m *Match) *Reply
+
+// RegisterMatcher 注册一个声明式匹配的插件，规则通过 Command、Regex、Keyword 等选项指定
+//
+//	plugin.RegisterMatcher("weather", WeatherPlugin, plugin.Command("天气", "weather"), plugin.GroupOnly())
+func RegisterMatcher(name string, fn MatchFunc, opts ...Option) {
+	add(name, func(ctx context.Context, bot Bot, e *event.MessageEvent) *Reply {
+		return fn(ctx, bot, e, MatchOf(ctx))
+	}, opts)
+	logRegistered(name, "message")
+}
+
+// 全局命令前缀
+var (
+	commandPrefixes   = []string{"/", "#"}
+	commandPrefixesMu sync.RWMutex
+)
+
+// SetCommandPrefixes 设置全局的命令前缀，默认为 "/" 和 "#"，传入 "" 表示允许不带前缀
+func SetCommandPrefixes(prefixes ...string) {
+	commandPrefixesMu.Lock()
+	defer commandPrefixesMu.Unlock()
+	commandPrefixes = append([]string(nil), prefixes...)
+}
+
+// CommandPrefixes 返回全局的命令前缀
+func CommandPrefixes() []string {
+	commandPrefixesMu.RLock()
+	defer commandPrefixesMu.RUnlock()
+	return append([]string(nil), commandPrefixes...)
+}
+
+// WithPrefixes 为插件单独设置命令前缀，覆盖全局设置
+func WithPrefixes(prefixes ...string) Option {
+	return func(p *Plugin) {
+		p.Prefixes = append([]string(nil), prefixes...)
+	}
+}
+
+// WithRule 添加自定义匹配规则
+func WithRule(rule Rule) Option {
+	return func(p *Plugin) {
+		p.Rules = append(p.Rules, rule)
+	}
+}
+
+// Command 匹配命令，names 中第一个为命令名，其余为别名
+//
+// 消息需要以"前缀+命令名"开头，且命令名后为空白或消息结束，如 "/天气 北京"，
+// 命令之后的内容写入 Match.Args 和 Match.Fields
+func Command(names ...string) Option {
+	return func(p *Plugin) {
+		p.Commands = append(p.Commands, names...)
+		p.Rules = append(p.Rules, func(e *event.MessageEvent, m *Match) bool {
+			return matchCommand(p.commandPrefixes(), names, m)
+		})
+	}
+}
+
+// Regex 使用正则表达式匹配消息的纯文本，捕获组写入 Match.Groups 和 Match.Named
+// 表达式无效时会 panic，应在注册时发现
+func Regex(pattern string) Option {
+	re := regexp.MustCompile(pattern)
+	return WithRule(func(e *event.MessageEvent, m *Match) bool {
+		groups := re.FindStringSubmatch(m.Text)
+		if groups == nil {
+			return false
+		}
+		m.Groups = groups
+		for i, name := range re.SubexpNames() {
+			if name == "" {
+				continue
+			}
+			if m.Named == nil {
+				m.Named = make(map[string]string)
+			}
+			m.Named[name] = groups[i]
+		}
+		return true
+	})
+}
+
+// Keyword 消息的纯文本包含任意一个关键词时匹配，匹配到的关键词写入 Match.Keyword
+func Keyword(keywords ...string) Option {
+	return WithRule(func(e *event.MessageEvent, m *Match) bool {
+		for _, keyword := range keywords {
+			if strings.Contains(m.Text, keyword) {
+				m.Keyword = keyword
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// OnlyToMe 只匹配@了机器人的消息
+func OnlyToMe() Option {
+	return WithRule(func(e *event.MessageEvent, m *Match) bool {
+		return e.IsAtMe
+	})
+}
+
+// GroupOnly 只匹配群聊消息
+func GroupOnly() Option {
+	return WithRule(func(e *event.MessageEvent, m *Match) bool {
+		return e.IsGroup()
+	})
+}
+
+// PrivateOnly 只匹配私聊消息
+func PrivateOnly() Option {
+	return WithRule(func(e *event.MessageEvent, m *Match) bool {
+		return e.IsPrivate()
+	})
+}
+
+// Match 检查消息是否满足插件的所有匹配规则，没有规则的插件匹配所有消息
+func (p *Plugin) Match(e *event.MessageEvent) (*Match, bool) {
+	m := &Match{Text: matchText(e)}
+	for _, rule := range p.Rules {
+		if !rule(e, m) {
+			return nil, false
+		}
+	}
+	return m, true
+}
+
+// commandPrefixes 返回插件使用的命令前缀
+func (p *Plugin) commandPrefixes() []string {
+	if p.Prefixes != nil {
+		return p.Prefixes
+	}
+	return CommandPrefixes()
+}
+
+// matchText 返回用于匹配的纯文本，没有解析出消息段时使用原始消息
+func matchText(e *event.MessageEvent) string {
+	if len(e.Segments) == 0 {
+		return strings.TrimSpace(e.Message)
+	}
+	return strings.TrimSpace(e.PlainText())
+}
+
+// matchCommand 检查文本是否以"前缀+命令名"开头
+func matchCommand(prefixes, names []string, m *Match) bool {
+	for _, prefix := range prefixes {
+		if !strings.HasPrefix(m.Text, prefix) {
+			continue
+		}
+		rest := m.Text[len(prefix):]
+		for _, name := range names {
+			if !strings.HasPrefix(rest, name) {
+				continue
+			}
+			args := rest[len(name):]
+			if args != "" && !strings.ContainsAny(args[:1], " \t\r\n") {
+				continue
+			}
+			m.Prefix = prefix
+			m.Command = name
+			m.Args = strings.TrimSpace(args)
+			m.Fields = strings.Fields(m.Args)
+			return true
+		}
+	}
+	return false
+}
+
+type matchKey struct{}
+
+// WithMatch 返回携带匹配结果的上下文，由框架在调用插件前设置
+func WithMatch(ctx context.Context, m *Match) context.Context {
+	return context.WithValue(ctx, matchKey{}, m)
+}
+
+// MatchOf 返回上下文中的匹配结果，没有时返回空的 Match
+func MatchOf(ctx context.Context) *Match {
+	if m, ok := ctx.Value(matchKey{}).(*Match); ok {
+		return m
+	}
+	return &Match{}
+}
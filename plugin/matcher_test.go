@@ -0,0 +1,46 @@
+package plugin_test
+
+import (
+	"testing"
+
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+func TestMatcherRules(t *testing.T) {
+	p := &plugin.Plugin{Name: "matcher_rules"}
+	for _, opt := range []plugin.Option{plugin.Command("天气", "weather"), plugin.GroupOnly()} {
+		opt(p)
+	}
+
+	tests := []struct {
+		message string
+		groupID int64
+		matched bool
+		command string
+		args    string
+	}{
+		{"/天气 北京 朝阳", 100, true, "天气", "北京 朝阳"},
+		{"#weather", 100, true, "weather", ""},
+		{"/天气预报", 100, false, "", ""},
+		{"天气 北京", 100, false, "", ""},
+		{"/天气 北京", 0, false, "", ""},
+	}
+	for _, tt := range tests {
+		m, ok := p.Match(&event.MessageEvent{Message: tt.message, GroupID: tt.groupID})
+		if ok != tt.matched {
+			t.Errorf("%q: expected matched=%v, got %v", tt.message, tt.matched, ok)
+			continue
+		}
+		if ok && (m.Command != tt.command || m.Args != tt.args) {
+			t.Errorf("%q: unexpected match %+v", tt.message, m)
+		}
+	}
+
+	re := &plugin.Plugin{Name: "matcher_regex"}
+	plugin.Regex(`^计算\s*(?P<a>\d+)\+(?P<b>\d+)$`)(re)
+	m, ok := re.Match(&event.MessageEvent{Message: "计算 1+2"})
+	if !ok || m.Named["a"] != "1" || m.Named["b"] != "2" || len(m.Groups) != 3 {
+		t.Errorf("Unexpected regex match: %v %+v", ok, m)
+	}
+}
@@ -0,0 +1,232 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/internal/store"
+)
+
+// Permission 插件或命令要求的权限等级
+type Permission int
+
+const (
+	PermMember    Permission = iota // 所有人（默认）
+	PermAdmin                       // 群管理员和群主
+	PermOwner                       // 群主
+	PermSuperuser                   // 超级用户
+)
+
+// String 返回权限等级的名称
+func (p Permission) String() string {
+	switch p {
+	case PermAdmin:
+		return "群管理员"
+	case PermOwner:
+		return "群主"
+	case PermSuperuser:
+		return "超级用户"
+	}
+	return "所有人"
+}
+
+// WithPermission 设置插件要求的权限等级，权限不足的用户不会触发插件
+func WithPermission(perm Permission) Option {
+	return func(p *Plugin) {
+		p.Permission = perm
+	}
+}
+
+// WithRole 要求用户拥有任意一个自定义角色才能触发插件，超级用户不受限制
+func WithRole(roles ...string) Option {
+	return func(p *Plugin) {
+		p.Roles = append(p.Roles, roles...)
+	}
+}
+
+var (
+	superusers   = make(map[int64]bool)
+	superusersMu sync.RWMutex
+)
+
+// SetSuperusers 设置超级用户（对应配置中的 Superusers），超级用户拥有所有权限
+func SetSuperusers(userIDs ...int64) {
+	superusersMu.Lock()
+	defer superusersMu.Unlock()
+
+	superusers = make(map[int64]bool, len(userIDs))
+	for _, id := range userIDs {
+		superusers[id] = true
+	}
+}
+
+// IsSuperuser 是否为超级用户
+func IsSuperuser(userID int64) bool {
+	superusersMu.RLock()
+	defer superusersMu.RUnlock()
+	return superusers[userID]
+}
+
+// HasPermission 判断消息的发送者是否拥有指定的权限等级
+//
+// 群主和管理员的身份来自消息中的发送者信息，私聊消息只有超级用户拥有 PermMember 以上的权限
+func HasPermission(e *event.MessageEvent, perm Permission) bool {
+	if IsSuperuser(e.UserID) {
+		return true
+	}
+	switch perm {
+	case PermMember:
+		return true
+	case PermAdmin:
+		return e.IsGroup() && e.Sender.IsAdmin()
+	case PermOwner:
+		return e.IsGroup() && e.Sender.IsOwner()
+	}
+	return false
+}
+
+// Authorized 判断消息的发送者能否触发插件：满足插件的权限等级，且拥有要求的任意一个自定义角色
+func (p *Plugin) Authorized(e *event.MessageEvent) bool {
+	if !HasPermission(e, p.Permission) {
+		return false
+	}
+	if len(p.Roles) == 0 || IsSuperuser(e.UserID) {
+		return true
+	}
+	for _, role := range p.Roles {
+		if HasRole(e.GroupID, e.UserID, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeniedReply 生成权限不足时的回复
+func DeniedReply(perm Permission, roles []string) *Reply {
+	if len(roles) > 0 {
+		return NewReply(fmt.Sprintf("❌ 权限不足，需要角色：%s", strings.Join(roles, "、"))).Quoted()
+	}
+	return NewReply(fmt.Sprintf("❌ 权限不足，需要%s权限", perm)).Quoted()
+}
+
+// 自定义角色：群号 -> 角色名 -> 成员QQ号，群号为0的角色在所有群和私聊中生效
+var (
+	roles    = make(map[int64]map[string][]int64)
+	roleFile string
+	rolesMu  sync.RWMutex
+)
+
+// LoadRoles 从文件加载自定义角色，之后的修改都会保存到该文件，文件不存在时使用空状态
+func LoadRoles(path string) error {
+	state := make(map[int64]map[string][]int64)
+	if err := store.Load(path, &state); err != nil {
+		return err
+	}
+
+	if state == nil {
+		state = make(map[int64]map[string][]int64)
+	}
+
+	rolesMu.Lock()
+	defer rolesMu.Unlock()
+
+	roles = state
+	roleFile = path
+	return nil
+}
+
+// HasRole 用户在群中是否拥有角色，群号为0的全局角色在所有群中生效
+func HasRole(groupID, userID int64, role string) bool {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+
+	for _, gid := range []int64{groupID, 0} {
+		for _, id := range roles[gid][role] {
+			if id == userID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GrantRole 授予用户在群中的角色，groupID 为0表示全局角色
+func GrantRole(groupID, userID int64, role string) error {
+	rolesMu.Lock()
+	defer rolesMu.Unlock()
+
+	for _, id := range roles[groupID][role] {
+		if id == userID {
+			return nil
+		}
+	}
+	if roles[groupID] == nil {
+		roles[groupID] = make(map[string][]int64)
+	}
+	roles[groupID][role] = append(roles[groupID][role], userID)
+	return saveRoles()
+}
+
+// RevokeRole 撤销用户在群中的角色
+func RevokeRole(groupID, userID int64, role string) error {
+	rolesMu.Lock()
+	defer rolesMu.Unlock()
+
+	members := roles[groupID][role]
+	for i, id := range members {
+		if id != userID {
+			continue
+		}
+		roles[groupID][role] = append(members[:i:i], members[i+1:]...)
+		if len(roles[groupID][role]) == 0 {
+			delete(roles[groupID], role)
+		}
+		if len(roles[groupID]) == 0 {
+			delete(roles, groupID)
+		}
+		return saveRoles()
+	}
+	return nil
+}
+
+// UserRoles 返回用户在群中拥有的角色（包括全局角色）
+func UserRoles(groupID, userID int64) []string {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+
+	seen := make(map[string]bool)
+	for _, gid := range []int64{groupID, 0} {
+		for role, members := range roles[gid] {
+			for _, id := range members {
+				if id == userID {
+					seen[role] = true
+				}
+			}
+		}
+	}
+
+	result := make([]string, 0, len(seen))
+	for role := range seen {
+		result = append(result, role)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// RoleMembers 返回群中拥有角色的用户（不包括全局角色）
+func RoleMembers(groupID int64, role string) []int64 {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+	return append([]int64(nil), roles[groupID][role]...)
+}
+
+// saveRoles 保存自定义角色，未调用 LoadRoles 时只保存在内存中，调用前需持有写锁
+func saveRoles() error {
+	if roleFile == "" {
+		return nil
+	}
+	return store.Save(roleFile, roles)
+}
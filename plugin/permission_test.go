@@ -0,0 +1,63 @@
+package plugin_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+func TestPermissions(t *testing.T) {
+	plugin.SetSuperusers(99)
+	defer plugin.SetSuperusers()
+	path := filepath.Join(t.TempDir(), "roles.json")
+	if err := plugin.LoadRoles(path); err != nil {
+		t.Fatalf("LoadRoles failed: %v", err)
+	}
+	defer plugin.LoadRoles(filepath.Join(t.TempDir(), "empty.json"))
+
+	member := &event.MessageEvent{GroupID: 1001, UserID: 1, Sender: event.Sender{Role: "member"}}
+	admin := &event.MessageEvent{GroupID: 1001, UserID: 2, Sender: event.Sender{Role: "admin"}}
+	owner := &event.MessageEvent{GroupID: 1001, UserID: 3, Sender: event.Sender{Role: "owner"}}
+	superuser := &event.MessageEvent{UserID: 99}
+
+	if plugin.HasPermission(member, plugin.PermAdmin) || !plugin.HasPermission(admin, plugin.PermAdmin) {
+		t.Error("Expected only admins to have PermAdmin")
+	}
+	if plugin.HasPermission(admin, plugin.PermOwner) || !plugin.HasPermission(owner, plugin.PermOwner) {
+		t.Error("Expected only owners to have PermOwner")
+	}
+	if !plugin.HasPermission(superuser, plugin.PermSuperuser) || plugin.HasPermission(owner, plugin.PermSuperuser) {
+		t.Error("Expected only superusers to have PermSuperuser")
+	}
+
+	p := &plugin.Plugin{Name: "role_test"}
+	plugin.WithRole("moderator")(p)
+	if p.Authorized(member) {
+		t.Error("Expected member without role to be rejected")
+	}
+	if err := plugin.GrantRole(1001, 1, "moderator"); err != nil {
+		t.Fatalf("GrantRole failed: %v", err)
+	}
+	if !p.Authorized(member) {
+		t.Error("Expected member with role to be authorized")
+	}
+	if p.Authorized(&event.MessageEvent{GroupID: 1002, UserID: 1}) {
+		t.Error("Expected roles to be scoped to their group")
+	}
+	if !p.Authorized(superuser) {
+		t.Error("Expected superuser to bypass role checks")
+	}
+
+	// 重新加载后角色保持不变
+	if err := plugin.LoadRoles(path); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if !plugin.HasRole(1001, 1, "moderator") {
+		t.Error("Expected role to be persisted")
+	}
+	if err := plugin.RevokeRole(1001, 1, "moderator"); err != nil || plugin.HasRole(1001, 1, "moderator") {
+		t.Errorf("Expected role to be revoked, err=%v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package plugin
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iamlibie/milonra-go/ratelimit"
+)
+
+// DefaultPriority 消息插件的默认优先级
+const DefaultPriority = 100
+
+// Plugin 已注册的消息插件
+type Plugin struct {
+	Name         string             // 插件名
+	Priority     int                // 优先级，数值越小越先执行，默认 DefaultPriority
+	Block        bool               // 处理了消息后是否阻止更低优先级的插件继续处理
+	Handler      HandlerFunc        // 处理函数，各种插件函数类型注册时都会被转换为 HandlerFunc
+	Rules        []Rule             // 匹配规则，全部满足时才调用 Handler，为空时匹配所有消息
+	Commands     []string           // 通过 Command 声明的命令名和别名
+	Prefixes     []string           // 命令前缀，nil 时使用全局设置
+	CommandSpecs []CommandSpec      // 通过 RegisterCommands 注册的命令定义，用于生成帮助
+	Permission   Permission         // 触发插件要求的权限等级
+	Roles        []string           // 触发插件要求的自定义角色（任意一个）
+	RateLimit    *ratelimit.Limiter // 每个用户触发该插件的频率限制，nil 表示只受全局限流影响
+	order        int                // 注册顺序，同优先级的插件按注册顺序排列
+}
+
+// Option 消息插件的注册选项
+type Option func(p *Plugin)
+
+// WithPriority 设置插件优先级，数值越小越先执行
+func WithPriority(priority int) Option {
+	return func(p *Plugin) {
+		p.Priority = priority
+	}
+}
+
+// WithBlock 插件处理了消息（返回非 nil 的回复）后，阻止更低优先级的插件继续处理
+func WithBlock() Option {
+	return func(p *Plugin) {
+		p.Block = true
+	}
+}
+
+// WithTimeout 设置插件单次调用的超时时间，等同于 SetTimeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *Plugin) {
+		SetTimeout(p.Name, timeout)
+	}
+}
+
+// WithRateLimit 限制每个用户每分钟最多触发该插件 n 次
+func WithRateLimit(n int) Option {
+	return func(p *Plugin) {
+		p.RateLimit = ratelimit.PerMinute(n)
+	}
+}
+
+// WithCooldown 设置插件的冷却时间，每个用户每隔 interval 最多触发一次
+func WithCooldown(interval time.Duration) Option {
+	return func(p *Plugin) {
+		p.RateLimit = ratelimit.Every(interval)
+	}
+}
+
+var (
+	registry    []*Plugin
+	registryMu  sync.RWMutex
+	registerSeq int
+)
+
+// add 注册消息插件，同名插件会被替换
+func add(name string, handler HandlerFunc, opts []Option) *Plugin {
+	p := &Plugin{
+		Name:     name,
+		Priority: DefaultPriority,
+		Handler:  handler,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registerSeq++
+	p.order = registerSeq
+	for i, existing := range registry {
+		if existing.Name == name {
+			registry[i] = p
+			return p
+		}
+	}
+	registry = append(registry, p)
+	return p
+}
+
+// Unregister 注销消息插件，同名的通知、请求和元事件插件也会被注销
+func Unregister(name string) {
+	RemoveReloadHook(name)
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	delete(plugins, name)
+	delete(noticePlugins, name)
+	delete(metaPlugins, name)
+	for i, p := range requestPlugins {
+		if p.Name == name {
+			requestPlugins = append(requestPlugins[:i], requestPlugins[i+1:]...)
+			break
+		}
+	}
+	for i, p := range registry {
+		if p.Name == name {
+			registry = append(registry[:i], registry[i+1:]...)
+			return
+		}
+	}
+}
+
+// List 返回所有已注册的消息插件，按优先级升序排列，同优先级按注册顺序排列
+func List() []*Plugin {
+	registryMu.RLock()
+	result := make([]*Plugin, len(registry))
+	copy(result, registry)
+	registryMu.RUnlock()
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Priority != result[j].Priority {
+			return result[i].Priority < result[j].Priority
+		}
+		return result[i].order < result[j].order
+	})
+	return result
+}
+
+// Tiers 按优先级将消息插件分组，优先级高（数值小）的组在前
+func Tiers() [][]*Plugin {
+	var tiers [][]*Plugin
+	for _, p := range List() {
+		if n := len(tiers); n > 0 && tiers[n-1][0].Priority == p.Priority {
+			tiers[n-1] = append(tiers[n-1], p)
+			continue
+		}
+		tiers = append(tiers, []*Plugin{p})
+	}
+	return tiers
+}
@@ -0,0 +1,60 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ReloadFunc 配置重新加载后调用的函数，返回错误表示插件未能应用新配置
+type ReloadFunc func() error
+
+var (
+	reloadHooks = make(map[string]ReloadFunc)
+	reloadMu    sync.RWMutex
+)
+
+// OnReload 注册插件在配置重新加载后调用的函数，同名插件再次注册时替换原来的函数
+func OnReload(name string, fn ReloadFunc) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	reloadHooks[name] = fn
+}
+
+// RemoveReloadHook 移除插件的重新加载函数
+func RemoveReloadHook(name string) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	delete(reloadHooks, name)
+}
+
+// NotifyReload 按插件名称顺序调用所有重新加载函数，返回出错或 panic 的插件及其错误
+func NotifyReload() map[string]error {
+	reloadMu.RLock()
+	names := make([]string, 0, len(reloadHooks))
+	hooks := make(map[string]ReloadFunc, len(reloadHooks))
+	for name, fn := range reloadHooks {
+		names = append(names, name)
+		hooks[name] = fn
+	}
+	reloadMu.RUnlock()
+	sort.Strings(names)
+
+	errs := make(map[string]error)
+	for _, name := range names {
+		if err := callReload(hooks[name]); err != nil {
+			errs[name] = err
+		}
+	}
+	return errs
+}
+
+// callReload 调用重新加载函数，panic 被转换为错误
+func callReload(fn ReloadFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,89 @@
+package plugin
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FailureStats 插件的失败统计
+type FailureStats struct {
+	Panics      int64     `json:"panics"`               // 累计 panic 次数
+	Consecutive int64     `json:"consecutive"`          // 连续 panic 次数，成功执行一次后清零
+	LastPanic   string    `json:"last_panic,omitempty"` // 最近一次 panic 的内容
+	LastPanicAt time.Time `json:"last_panic_at"`        // 最近一次 panic 的时间
+	Disabled    bool      `json:"disabled"`             // 是否因连续 panic 被自动禁用
+}
+
+var (
+	failures   = make(map[string]*FailureStats)
+	failuresMu sync.RWMutex
+
+	// 插件连续 panic 多少次后自动禁用，<= 0 表示不自动禁用
+	maxPanics = 5
+)
+
+// SetMaxPanics 设置插件连续 panic 多少次后自动禁用，<= 0 表示不自动禁用
+func SetMaxPanics(n int) {
+	failuresMu.Lock()
+	defer failuresMu.Unlock()
+	maxPanics = n
+}
+
+// RecordPanic 记录插件的一次 panic，返回插件是否因此被自动禁用
+func RecordPanic(name string, value interface{}) (disabled bool) {
+	failuresMu.Lock()
+	defer failuresMu.Unlock()
+
+	stats, ok := failures[name]
+	if !ok {
+		stats = &FailureStats{}
+		failures[name] = stats
+	}
+	stats.Panics++
+	stats.Consecutive++
+	stats.LastPanic = fmt.Sprint(value)
+	stats.LastPanicAt = time.Now()
+
+	if maxPanics > 0 && stats.Consecutive >= int64(maxPanics) && !stats.Disabled {
+		stats.Disabled = true
+		return true
+	}
+	return false
+}
+
+// RecordSuccess 记录插件的一次正常执行，清零连续 panic 次数
+func RecordSuccess(name string) {
+	failuresMu.Lock()
+	defer failuresMu.Unlock()
+	if stats, ok := failures[name]; ok {
+		stats.Consecutive = 0
+	}
+}
+
+// IsFailureDisabled 插件是否因连续 panic 被自动禁用
+func IsFailureDisabled(name string) bool {
+	failuresMu.RLock()
+	defer failuresMu.RUnlock()
+	stats, ok := failures[name]
+	return ok && stats.Disabled
+}
+
+// ResetFailures 清空插件的失败统计，并解除自动禁用
+func ResetFailures(name string) {
+	failuresMu.Lock()
+	defer failuresMu.Unlock()
+	delete(failures, name)
+}
+
+// GetFailures 获取所有发生过 panic 的插件的失败统计
+func GetFailures() map[string]FailureStats {
+	failuresMu.RLock()
+	defer failuresMu.RUnlock()
+
+	result := make(map[string]FailureStats, len(failures))
+	for name, stats := range failures {
+		result[name] = *stats
+	}
+	return result
+}
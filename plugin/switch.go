@@ -0,0 +1,177 @@
+package plugin
+
+import (
+	"sync"
+
+	"github.com/iamlibie/milonra-go/internal/store"
+)
+
+// switchState 插件开关状态，会被持久化到文件
+type switchState struct {
+	Global map[string]bool           `json:"global"` // 全局开关
+	Groups map[int64]map[string]bool `json:"groups"` // 群号 -> 插件开关
+	Users  map[int64]map[string]bool `json:"users"`  // QQ号 -> 插件开关
+}
+
+var (
+	switches = switchState{
+		Global: make(map[string]bool),
+		Groups: make(map[int64]map[string]bool),
+		Users:  make(map[int64]map[string]bool),
+	}
+	enabledPlugins map[string]bool // 配置中的启用列表，nil 表示全部启用
+	alwaysEnabled  = make(map[string]bool)
+	switchFile     string
+	switchMu       sync.RWMutex
+)
+
+// SetEnabledPlugins 设置默认启用的插件列表（对应配置中的 EnabledPlugins），
+// 不在列表中的插件默认禁用，传入空列表表示全部启用
+func SetEnabledPlugins(names []string) {
+	switchMu.Lock()
+	defer switchMu.Unlock()
+
+	if len(names) == 0 {
+		enabledPlugins = nil
+		return
+	}
+	enabledPlugins = make(map[string]bool, len(names))
+	for _, name := range names {
+		enabledPlugins[name] = true
+	}
+}
+
+// LoadSwitches 从文件加载插件开关状态，之后的修改都会保存到该文件，文件不存在时使用空状态
+func LoadSwitches(path string) error {
+	state := switchState{}
+	if err := store.Load(path, &state); err != nil {
+		return err
+	}
+
+	switchMu.Lock()
+	defer switchMu.Unlock()
+
+	switches = normalizeSwitches(state)
+	switchFile = path
+	return nil
+}
+
+// normalizeSwitches 补全文件中缺失的字段
+func normalizeSwitches(state switchState) switchState {
+	if state.Global == nil {
+		state.Global = make(map[string]bool)
+	}
+	if state.Groups == nil {
+		state.Groups = make(map[int64]map[string]bool)
+	}
+	if state.Users == nil {
+		state.Users = make(map[int64]map[string]bool)
+	}
+	return state
+}
+
+// IsEnabled 判断插件对某个群或用户是否启用，groupID 为0表示私聊
+//
+// 优先级从高到低：用户开关 > 群开关 > 全局开关 > 配置中的启用列表，都未设置时默认启用
+func IsEnabled(name string, groupID, userID int64) bool {
+	switchMu.RLock()
+	defer switchMu.RUnlock()
+
+	if alwaysEnabled[name] {
+		return true
+	}
+	if enabled, ok := switches.Users[userID][name]; ok && userID != 0 {
+		return enabled
+	}
+	if enabled, ok := switches.Groups[groupID][name]; ok && groupID != 0 {
+		return enabled
+	}
+	if enabled, ok := switches.Global[name]; ok {
+		return enabled
+	}
+	if enabledPlugins != nil {
+		return enabledPlugins[name]
+	}
+	return true
+}
+
+// SetEnabled 设置插件的全局开关
+func SetEnabled(name string, enabled bool) error {
+	switchMu.Lock()
+	defer switchMu.Unlock()
+
+	switches.Global[name] = enabled
+	return saveSwitches()
+}
+
+// SetGroupEnabled 设置插件在某个群的开关，覆盖全局开关
+func SetGroupEnabled(groupID int64, name string, enabled bool) error {
+	switchMu.Lock()
+	defer switchMu.Unlock()
+
+	setOverride(switches.Groups, groupID, name, enabled)
+	return saveSwitches()
+}
+
+// SetUserEnabled 设置插件对某个用户的开关，覆盖群开关和全局开关
+func SetUserEnabled(userID int64, name string, enabled bool) error {
+	switchMu.Lock()
+	defer switchMu.Unlock()
+
+	setOverride(switches.Users, userID, name, enabled)
+	return saveSwitches()
+}
+
+// ResetGroupEnabled 清除插件在某个群的开关，恢复为全局设置
+func ResetGroupEnabled(groupID int64, name string) error {
+	switchMu.Lock()
+	defer switchMu.Unlock()
+
+	clearOverride(switches.Groups, groupID, name)
+	return saveSwitches()
+}
+
+// ResetUserEnabled 清除插件对某个用户的开关
+func ResetUserEnabled(userID int64, name string) error {
+	switchMu.Lock()
+	defer switchMu.Unlock()
+
+	clearOverride(switches.Users, userID, name)
+	return saveSwitches()
+}
+
+// SetAlwaysEnabled 标记插件始终启用，不受任何开关影响（用于插件管理等内置插件）
+func SetAlwaysEnabled(name string) {
+	switchMu.Lock()
+	defer switchMu.Unlock()
+	alwaysEnabled[name] = true
+}
+
+// isAlwaysEnabled 插件是否被标记为始终启用
+func isAlwaysEnabled(name string) bool {
+	switchMu.RLock()
+	defer switchMu.RUnlock()
+	return alwaysEnabled[name]
+}
+
+func setOverride(overrides map[int64]map[string]bool, id int64, name string, enabled bool) {
+	if overrides[id] == nil {
+		overrides[id] = make(map[string]bool)
+	}
+	overrides[id][name] = enabled
+}
+
+func clearOverride(overrides map[int64]map[string]bool, id int64, name string) {
+	delete(overrides[id], name)
+	if len(overrides[id]) == 0 {
+		delete(overrides, id)
+	}
+}
+
+// saveSwitches 保存开关状态，未调用 LoadSwitches 时只保存在内存中，调用前需持有写锁
+func saveSwitches() error {
+	if switchFile == "" {
+		return nil
+	}
+	return store.Save(switchFile, switches)
+}
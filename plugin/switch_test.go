@@ -0,0 +1,47 @@
+package plugin_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+func TestPluginSwitches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plugin_switches.json")
+	if err := plugin.LoadSwitches(path); err != nil {
+		t.Fatalf("LoadSwitches failed: %v", err)
+	}
+	defer plugin.LoadSwitches(filepath.Join(t.TempDir(), "empty.json"))
+
+	if err := plugin.SetGroupEnabled(1001, "noisy", false); err != nil {
+		t.Fatalf("SetGroupEnabled failed: %v", err)
+	}
+	if err := plugin.SetUserEnabled(42, "noisy", true); err != nil {
+		t.Fatalf("SetUserEnabled failed: %v", err)
+	}
+
+	if plugin.IsEnabled("noisy", 1001, 7) {
+		t.Error("Expected noisy to be disabled in group 1001")
+	}
+	if !plugin.IsEnabled("noisy", 1002, 7) {
+		t.Error("Expected noisy to stay enabled in other groups")
+	}
+	if !plugin.IsEnabled("noisy", 1001, 42) {
+		t.Error("Expected user override to take precedence over group override")
+	}
+
+	// 重新加载后状态保持不变
+	if err := plugin.LoadSwitches(path); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if plugin.IsEnabled("noisy", 1001, 7) {
+		t.Error("Expected group override to be persisted")
+	}
+
+	plugin.SetEnabledPlugins([]string{"weather"})
+	defer plugin.SetEnabledPlugins(nil)
+	if plugin.IsEnabled("other", 1002, 7) || !plugin.IsEnabled("weather", 1002, 7) {
+		t.Error("Expected EnabledPlugins to act as the default allow list")
+	}
+}
@@ -0,0 +1,124 @@
+// Package ratelimit 实现令牌桶限流，用于限制用户、群和插件触发插件的频率
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter 按 key 分别计算的令牌桶限流器
+type Limiter struct {
+	rate  float64 // 每秒补充的令牌数
+	burst float64 // 桶的容量
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+}
+
+// bucket 一个 key 的令牌桶
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter 创建限流器，每秒补充 rate 个令牌，最多积累 burst 个
+func NewLimiter(rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// PerMinute 创建每分钟最多 n 次的限流器，允许一次性用完 n 次
+func PerMinute(n int) *Limiter {
+	return NewLimiter(float64(n)/60, n)
+}
+
+// Every 创建每隔 interval 最多一次的限流器，即冷却时间
+func Every(interval time.Duration) *Limiter {
+	return NewLimiter(1/interval.Seconds(), 1)
+}
+
+// Check 检查 key 是否还有令牌（不消耗），没有时返回需要等待的时间
+func (l *Limiter) Check(key string) (retryAfter time.Duration, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refill(key, time.Now())
+	if b.tokens >= 1 {
+		return 0, true
+	}
+	return l.wait(b), false
+}
+
+// Take 消耗 key 的一个令牌，令牌不足时不会变为负数
+func (l *Limiter) Take(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refill(key, time.Now())
+	b.tokens = math.Max(b.tokens-1, 0)
+}
+
+// Allow 检查并消耗 key 的一个令牌
+func (l *Limiter) Allow(key string) (retryAfter time.Duration, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refill(key, time.Now())
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	return l.wait(b), false
+}
+
+// Refund 归还 key 的一个令牌，用于 Allow 预留的次数最终没有使用的情况，令牌不会超过桶的容量
+func (l *Limiter) Refund(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refill(key, time.Now())
+	b.tokens = math.Min(l.burst, b.tokens+1)
+}
+
+// refill 按经过的时间补充令牌，调用前需持有锁
+func (l *Limiter) refill(key string, now time.Time) *bucket {
+	l.calls++
+	if l.calls%1024 == 0 {
+		l.prune(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+		return b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	return b
+}
+
+// wait 返回令牌补充到1个所需的时间，调用前需持有锁
+func (l *Limiter) wait(b *bucket) time.Duration {
+	if l.rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// prune 删除已经补满的令牌桶，避免长期运行时内存增长，调用前需持有锁
+func (l *Limiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMessage 默认的限流提示，{retry} 会被替换为需要等待的秒数
+const DefaultMessage = "⏳ 操作太频繁，请{retry}秒后再试"
+
+// Config 全局限流配置
+type Config struct {
+	Enabled                bool   `json:"enabled"`                   // 是否启用
+	RequestsPerMinute      int    `json:"requests_per_minute"`       // 每个用户每分钟最多触发插件的次数，0 表示不限制
+	GroupRequestsPerMinute int    `json:"group_requests_per_minute"` // 每个群每分钟最多触发插件的次数，0 表示不限制
+	Message                string `json:"message"`                   // 被限流时的提示，{retry} 为需要等待的秒数，默认 DefaultMessage
+	Silent                 bool   `json:"silent"`                    // 被限流时不提示，直接丢弃
+}
+
+var (
+	config   Config
+	users    *Limiter
+	groups   *Limiter
+	notified = make(map[string]time.Time) // 已提示过的 key，在冷却结束前不再重复提示
+	globalMu sync.RWMutex
+)
+
+// Configure 设置全局限流，可以在运行时重新调用
+func Configure(c Config) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	config = c
+	users, groups = nil, nil
+	if !c.Enabled {
+		return
+	}
+	if c.RequestsPerMinute > 0 {
+		users = PerMinute(c.RequestsPerMinute)
+	}
+	if c.GroupRequestsPerMinute > 0 {
+		groups = PerMinute(c.GroupRequestsPerMinute)
+	}
+}
+
+// Allow 检查并消耗用户和群的一次触发，群号为0表示私聊；任意一个被限流时都不消耗
+//
+// 并发的消息不会同时通过检查，插件最终没有处理消息时应调用 Refund 归还
+func Allow(groupID, userID int64) (retryAfter time.Duration, ok bool) {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+
+	if users != nil {
+		if wait, allowed := users.Allow(userKey(userID)); !allowed {
+			if groups != nil && groupID != 0 {
+				if groupWait, ok := groups.Check(groupKey(groupID)); !ok {
+					wait = max(wait, groupWait)
+				}
+			}
+			return wait, false
+		}
+	}
+	if groups != nil && groupID != 0 {
+		if wait, allowed := groups.Allow(groupKey(groupID)); !allowed {
+			if users != nil {
+				users.Refund(userKey(userID))
+			}
+			return wait, false
+		}
+	}
+	return 0, true
+}
+
+// Refund 归还 Allow 消耗的一次触发
+func Refund(groupID, userID int64) {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+
+	if users != nil {
+		users.Refund(userKey(userID))
+	}
+	if groups != nil && groupID != 0 {
+		groups.Refund(groupKey(groupID))
+	}
+}
+
+// Reject 返回被限流时的提示，静默模式或在本次冷却中已经提示过时返回空字符串
+func Reject(userID int64, retryAfter time.Duration) string {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	if config.Silent {
+		return ""
+	}
+
+	key := userKey(userID)
+	now := time.Now()
+	if until, ok := notified[key]; ok && now.Before(until) {
+		return ""
+	}
+	for k, until := range notified {
+		if now.After(until) {
+			delete(notified, k)
+		}
+	}
+	notified[key] = now.Add(retryAfter)
+
+	message := config.Message
+	if message == "" {
+		message = DefaultMessage
+	}
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return strings.ReplaceAll(message, "{retry}", fmt.Sprint(max(seconds, 1)))
+}
+
+func userKey(userID int64) string {
+	return fmt.Sprintf("u:%d", userID)
+}
+
+func groupKey(groupID int64) string {
+	return fmt.Sprintf("g:%d", groupID)
+}
@@ -0,0 +1,46 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/iamlibie/milonra-go/ratelimit"
+)
+
+func TestLimiter(t *testing.T) {
+	limiter := ratelimit.NewLimiter(10, 2)
+	for i := 0; i < 2; i++ {
+		if _, ok := limiter.Allow("user"); !ok {
+			t.Fatalf("Expected request %d to be allowed within burst", i+1)
+		}
+	}
+	retryAfter, ok := limiter.Allow("user")
+	if ok || retryAfter <= 0 || retryAfter > 100*time.Millisecond {
+		t.Fatalf("Expected third request to be limited with a short retry, got ok=%v retry=%v", ok, retryAfter)
+	}
+	if _, ok := limiter.Check("other"); !ok {
+		t.Error("Expected buckets to be independent per key")
+	}
+	time.Sleep(retryAfter + 10*time.Millisecond)
+	if _, ok := limiter.Allow("user"); !ok {
+		t.Error("Expected tokens to refill over time")
+	}
+}
+
+func TestGlobalRateLimit(t *testing.T) {
+	ratelimit.Configure(ratelimit.Config{Enabled: true, RequestsPerMinute: 1, Message: "wait {retry}s"})
+	defer ratelimit.Configure(ratelimit.Config{})
+	if _, ok := ratelimit.Allow(1001, 5); !ok {
+		t.Fatal("Expected first request to be allowed")
+	}
+	retryAfter, ok := ratelimit.Allow(1001, 5)
+	if ok {
+		t.Fatal("Expected user to be limited after using the quota")
+	}
+	if msg := ratelimit.Reject(5, retryAfter); msg != "wait 60s" {
+		t.Errorf("Unexpected cooldown message %q", msg)
+	}
+	if msg := ratelimit.Reject(5, retryAfter); msg != "" {
+		t.Errorf("Expected cooldown message only once per window, got %q", msg)
+	}
+}
@@ -0,0 +1,105 @@
+package sdk
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/iamlibie/milonra-go/logging"
+)
+
+// authStats 被拒绝的反向 WebSocket 连接次数
+type authStats struct {
+	token  atomic.Int64 // 访问令牌缺失或错误
+	origin atomic.Int64 // Origin 不在允许列表中
+}
+
+// snapshot 返回各原因的拒绝次数
+func (s *authStats) snapshot() map[string]int64 {
+	return map[string]int64{
+		"token":  s.token.Load(),
+		"origin": s.origin.Load(),
+	}
+}
+
+// RejectedConnections 返回因访问令牌（token）或 Origin（origin）被拒绝的反向 WebSocket 连接次数
+func (mb *MiloraBot) RejectedConnections() map[string]int64 {
+	return mb.rejected.snapshot()
+}
+
+// authorize 检查反向 WebSocket 连接的访问令牌和 Origin，不通过时写入错误响应、记录日志并计数
+//
+// 令牌按 OneBot 标准从 Authorization: Bearer <token> 请求头或 access_token 查询参数读取，
+// 缺失时返回 401，错误时返回 403。
+func (mb *MiloraBot) authorize(w http.ResponseWriter, r *http.Request) bool {
+	security := mb.currentConfig().Security
+	logger := logging.Logger().With("remote", r.RemoteAddr)
+
+	if security.AccessToken != "" {
+		token, ok := requestToken(r)
+		if !ok {
+			mb.rejected.token.Add(1)
+			logger.Warn("拒绝连接：缺少访问令牌")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return false
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(security.AccessToken)) != 1 {
+			mb.rejected.token.Add(1)
+			logger.Warn("拒绝连接：访问令牌错误")
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return false
+		}
+	}
+
+	if !mb.checkOrigin(r) {
+		mb.rejected.origin.Add(1)
+		logger.Warn("拒绝连接：Origin 不在允许列表中", "origin", r.Header.Get("Origin"))
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// checkOrigin 检查请求的 Origin，配置了 CheckOrigin 时以它为准
+//
+// 没有 Origin 请求头的请求（OneBot 实现等非浏览器客户端）总是允许；
+// 否则 Origin 需要在 security.allowed_origins 中（"*" 表示允许所有），列表为空时只允许同源请求。
+func (mb *MiloraBot) checkOrigin(r *http.Request) bool {
+	config := mb.currentConfig()
+	if config.CheckOrigin != nil {
+		return config.CheckOrigin(r)
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	allowed := config.Security.AllowedOrigins
+	if len(allowed) == 0 {
+		u, err := url.Parse(origin)
+		return err == nil && strings.EqualFold(u.Host, r.Host)
+	}
+	for _, o := range allowed {
+		if o == "*" || strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestToken 读取请求中的访问令牌
+func requestToken(r *http.Request) (string, bool) {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		scheme, token, ok := strings.Cut(auth, " ")
+		if ok && (strings.EqualFold(scheme, "Bearer") || strings.EqualFold(scheme, "Token")) {
+			return strings.TrimSpace(token), true
+		}
+		return "", false
+	}
+	if token := r.URL.Query().Get("access_token"); token != "" {
+		return token, true
+	}
+	return "", false
+}
@@ -0,0 +1,153 @@
+package sdk
+
+import (
+	"sort"
+
+	"github.com/iamlibie/milonra-go/bot"
+	"github.com/iamlibie/milonra-go/logging"
+	mplugin "github.com/iamlibie/milonra-go/plugin"
+)
+
+// botConn 一个机器人账号的连接及其健康状态
+type botConn struct {
+	bot    *bot.Bot
+	health *connHealth
+}
+
+// BotStatus 机器人账号的连接状态
+type BotStatus struct {
+	SelfID            int64  `json:"self_id"`
+	Connected         bool   `json:"connected"`
+	Healthy           bool   `json:"healthy"`
+	LastHeartbeat     int64  `json:"last_heartbeat,omitempty"`     // 最近一次心跳的 Unix 时间
+	HeartbeatInterval string `json:"heartbeat_interval,omitempty"` // 心跳间隔
+}
+
+// addBot 记录新建立的连接，同一账号的旧连接被替换；
+// 账号未知（guessed 为 true，使用的是配置的 BotID）时不替换在线的连接，等收到上报后再记录
+func (mb *MiloraBot) addBot(b *bot.Bot, health *connHealth, guessed bool) {
+	selfID := b.GetSelfID()
+	mb.botsMu.Lock()
+	if conn, ok := mb.bots[selfID]; guessed && ok && conn.health.snapshot().Connected {
+		mb.botsMu.Unlock()
+		return
+	}
+	mb.bots[selfID] = &botConn{bot: b, health: health}
+	mb.botsMu.Unlock()
+	mplugin.RegisterBot(selfID, b)
+}
+
+// removeBot 连接断开后从在线账号中移除，该账号已被新的连接替换时只注销旧连接
+func (mb *MiloraBot) removeBot(b *bot.Bot) {
+	selfID := b.GetSelfID()
+	mb.botsMu.Lock()
+	if conn, ok := mb.bots[selfID]; ok && conn.bot == b {
+		delete(mb.bots, selfID)
+	}
+	mb.botsMu.Unlock()
+	mplugin.UnregisterBot(selfID, b)
+}
+
+// rekeyBot 从上报中得知连接对应的账号后，将连接改为记录在该账号下
+func (mb *MiloraBot) rekeyBot(b *bot.Bot, health *connHealth, selfID int64) {
+	old := b.GetSelfID()
+	mb.botsMu.Lock()
+	if conn, ok := mb.bots[old]; ok && conn.bot == b {
+		delete(mb.bots, old)
+	}
+	mb.bots[selfID] = &botConn{bot: b, health: health}
+	mb.botsMu.Unlock()
+
+	mplugin.UnregisterBot(old, b)
+	b.SetSelfID(selfID)
+	mplugin.RegisterBot(selfID, b)
+	logging.Logger().Info("已识别连接的机器人账号", logging.KeySelfID, selfID, "previous", old)
+}
+
+// GetBot 返回 selfID 账号当前连接的机器人实例（用于高级操作或主动发送消息），selfID 为0时返回在线账号中ID最小的一个，
+// 账号不在线时返回 nil
+func (mb *MiloraBot) GetBot(selfID int64) *bot.Bot {
+	if selfID == 0 {
+		if bots := mb.Bots(); len(bots) > 0 {
+			return bots[0]
+		}
+		return nil
+	}
+	mb.botsMu.RLock()
+	defer mb.botsMu.RUnlock()
+	if conn, ok := mb.bots[selfID]; ok && conn.health.snapshot().Connected {
+		return conn.bot
+	}
+	return nil
+}
+
+// Bots 返回所有在线账号的机器人实例，按账号排序
+func (mb *MiloraBot) Bots() []*bot.Bot {
+	mb.botsMu.RLock()
+	defer mb.botsMu.RUnlock()
+	var bots []*bot.Bot
+	for _, conn := range mb.bots {
+		if conn.health.snapshot().Connected {
+			bots = append(bots, conn.bot)
+		}
+	}
+	sort.Slice(bots, func(i, j int) bool { return bots[i].GetSelfID() < bots[j].GetSelfID() })
+	return bots
+}
+
+// BotStatuses 返回所有在线账号的状态，按账号排序
+func (mb *MiloraBot) BotStatuses() []BotStatus {
+	mb.botsMu.RLock()
+	defer mb.botsMu.RUnlock()
+	statuses := make([]BotStatus, 0, len(mb.bots))
+	for selfID, conn := range mb.bots {
+		health := conn.health.snapshot()
+		status := BotStatus{SelfID: selfID, Connected: health.Connected, Healthy: health.Healthy}
+		if !health.LastHeartbeat.IsZero() {
+			status.LastHeartbeat = health.LastHeartbeat.Unix()
+			status.HeartbeatInterval = health.Interval.String()
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].SelfID < statuses[j].SelfID })
+	return statuses
+}
+
+// healthSnapshot 汇总所有账号的健康状态：任意账号在线即为已连接，至少一个账号在线且所有账号都在线并健康才为健康，
+// 心跳取最近的一次
+func (mb *MiloraBot) healthSnapshot() healthSnapshot {
+	mb.botsMu.RLock()
+	defer mb.botsMu.RUnlock()
+	var summary healthSnapshot
+	healthy := true
+	for _, conn := range mb.bots {
+		health := conn.health.snapshot()
+		summary.Connected = summary.Connected || health.Connected
+		healthy = healthy && health.Connected && health.Healthy
+		if health.LastHeartbeat.After(summary.LastHeartbeat) {
+			summary.LastHeartbeat = health.LastHeartbeat
+			summary.Interval = health.Interval
+		}
+	}
+	summary.Healthy = summary.Connected && healthy
+	return summary
+}
+
+// httpConn 返回通过 HTTP 通信的账号的连接记录，第一次收到该账号的上报时创建
+func (mb *MiloraBot) httpConn(selfID int64) *botConn {
+	mb.botsMu.Lock()
+	conn, ok := mb.bots[selfID]
+	created := !ok || !conn.health.snapshot().Connected
+	if created {
+		health := &connHealth{healthy: true}
+		health.onConnect()
+		conn = &botConn{bot: mb.httpBot(selfID, mb.httpTransport(), health), health: health}
+		mb.bots[selfID] = conn
+	}
+	mb.botsMu.Unlock()
+
+	if created {
+		mplugin.RegisterBot(selfID, conn.bot)
+	}
+	return conn
+}
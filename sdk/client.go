@@ -0,0 +1,92 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/iamlibie/milonra-go/logging"
+)
+
+// ErrNoOneBotURL 未配置 OneBot 实现的正向 WebSocket 地址
+var ErrNoOneBotURL = errors.New("未配置 lagrange.url")
+
+// Connect 以正向 WebSocket 主动连接配置中的 OneBot 实现（lagrange.url），收到的事件与反向连接一样分发给插件
+//
+// 启用 lagrange.reconnect 时，连接失败或断开后按指数退避重连：等待时间从 reconnect_interval 开始，
+// 每次失败翻倍，不超过 max_reconnect_interval，连接成功后重置。
+// 一直运行到 ctx 取消或服务停止（返回 nil），未启用重连时连接断开即返回，首次连接失败时返回错误。
+// 配置了 lagrange.url 时 Start 会自动调用。
+func (mb *MiloraBot) Connect(ctx context.Context) error {
+	cfg := mb.currentConfig().Lagrange
+	if cfg.URL == "" {
+		return ErrNoOneBotURL
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		// 服务停止时同样结束
+		select {
+		case <-mb.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	header := http.Header{}
+	if cfg.AccessToken != "" {
+		header.Set("Authorization", "Bearer "+cfg.AccessToken)
+	}
+	logger := logging.Logger().With("url", cfg.URL)
+
+	delay := cfg.ReconnectInterval
+	for {
+		conn, resp, err := websocket.DefaultDialer.DialContext(ctx, cfg.URL, header)
+		if ctx.Err() != nil {
+			if conn != nil {
+				conn.Close()
+			}
+			return nil
+		}
+		if err != nil {
+			if resp != nil {
+				err = fmt.Errorf("%w（HTTP %d）", err, resp.StatusCode)
+			}
+			if !cfg.Reconnect {
+				return fmt.Errorf("连接 OneBot 实现失败: %w", err)
+			}
+			logger.Warn("连接 OneBot 实现失败，等待重连", "retry_after", delay.String(), logging.KeyError, err)
+		} else {
+			logger.Info("已连接到 OneBot 实现", logging.KeySelfID, mb.currentConfig().BotID)
+			mb.serveConn(ctx, conn, 0)
+			conn.Close()
+			if ctx.Err() != nil || !cfg.Reconnect {
+				return nil
+			}
+			delay = cfg.ReconnectInterval
+			logger.Warn("与 OneBot 实现的连接已断开，等待重连", "retry_after", delay.String())
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+		delay = nextReconnectDelay(delay, cfg.MaxReconnectInterval)
+	}
+}
+
+// nextReconnectDelay 返回下一次重连的等待时间：翻倍，不超过 max（max 不大于0时不设上限）
+func nextReconnectDelay(delay, max time.Duration) time.Duration {
+	delay *= 2
+	if max > 0 && delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -0,0 +1,437 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iamlibie/milonra-go/api"
+	"github.com/iamlibie/milonra-go/logging"
+)
+
+// EnvPrefix 通用环境变量前缀，MILONRA_<字段路径> 可以覆盖任意配置，
+// 嵌套字段用双下划线分隔，例如 MILONRA_SECURITY__RATE_LIMIT__ENABLED=false
+const EnvPrefix = "MILONRA_"
+
+// envAliases 与 docker-compose.yml 一致的环境变量
+var envAliases = map[string]string{
+	"BOT_ID":       "bot_id",
+	"API_PORT":     "port",
+	"LOG_LEVEL":    "log_level",
+	"PLUGIN_DIR":   "plugin_dir",
+	"DATA_DIR":     "data_dir",
+	"SUPERUSERS":   "superusers",
+	"LAGRANGE_URL": "lagrange.url",
+}
+
+// LagrangeConfig OneBot 实现（Lagrange、NapCat 等）的正向 WebSocket 连接配置，
+// URL 为空时只等待 OneBot 实现反向连接到本服务
+type LagrangeConfig struct {
+	URL                  string        `json:"url"`                    // 正向 WebSocket 地址，如 ws://localhost:8081
+	AccessToken          string        `json:"access_token"`           // 访问令牌，通过 Authorization 请求头发送
+	Reconnect            bool          `json:"reconnect"`              // 断开后是否自动重连
+	ReconnectInterval    time.Duration `json:"reconnect_interval"`     // 首次重连的等待时间，之后每次失败翻倍，默认 5秒
+	MaxReconnectInterval time.Duration `json:"max_reconnect_interval"` // 重连等待时间的上限，默认 1分钟
+}
+
+// HTTPConfig OneBot HTTP API 和 HTTP POST 上报的配置，用于只能通过 HTTP 访问 OneBot 实现的部署，
+// APIURL 不为空时启用：API 通过 HTTP 调用，事件通过 HTTP POST 上报到 EventPath
+type HTTPConfig struct {
+	APIURL            string        `json:"api_url"`             // OneBot 实现的 HTTP API 地址，如 http://localhost:5700
+	AccessToken       string        `json:"access_token"`        // 调用 HTTP API 时发送的访问令牌
+	EventPath         string        `json:"event_path"`          // 接收 HTTP POST 上报的路径，默认 "/onebot/event"
+	Secret            string        `json:"secret"`              // 上报签名密钥，不为空时校验 X-Signature 请求头（HMAC-SHA1）
+	QuickReplyTimeout time.Duration `json:"quick_reply_timeout"` // 等待插件回复以快速操作响应上报的时间，默认 500毫秒，负数表示不使用快速操作
+}
+
+// LoadConfig 加载配置文件和环境变量，path 为空时只读取环境变量
+//
+// 配置文件可以是 JSON，也可以是每行一个 key = value 的纯文本（# 开头为注释，嵌套字段用 . 分隔，
+// 列表用逗号分隔），字段名与 JSON 相同。时长字段支持 "15s"、"1m30s" 等字符串，纯数字表示秒。
+//
+// 优先级从低到高：默认值 < 配置文件 < 环境变量。未设置的字段使用与 NewMiloraBot 相同的默认值
+// （日志和自动加载插件默认开启，限流、发送队列和超长消息处理默认关闭），bot_id 必须设置。
+// 返回的配置在创建 MiloraBot 之前仍然可以在代码中修改。
+func LoadConfig(path string) (*MiloraBotConfig, error) {
+	values := make(map[string]interface{})
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取配置文件失败: %w", err)
+		}
+		values, err = parseConfig(data)
+		if err != nil {
+			return nil, fmt.Errorf("配置文件 %s: %w", path, err)
+		}
+	}
+	applyEnv(values, os.Environ())
+
+	config := &MiloraBotConfig{EnableLog: true, AutoLoadPlugins: true}
+	if err := decodeConfig(values, config); err != nil {
+		return nil, err
+	}
+	if config.Port != "" && !strings.Contains(config.Port, ":") {
+		config.Port = ":" + config.Port
+	}
+	config.setDefaults()
+	config.loaded = true
+	config.path = path
+
+	err := config.Validate()
+	if config.BotID == 0 {
+		err = errors.Join(errors.New("bot_id: 未设置，请填写机器人QQ号"), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate 检查配置是否有效，返回所有无效字段的错误
+func (c *MiloraBotConfig) Validate() error {
+	var errs []error
+	invalid := func(field, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	if c.Port != "" {
+		_, port, err := net.SplitHostPort(c.Port)
+		if n, convErr := strconv.Atoi(port); err != nil || convErr != nil || n < 0 || n > 65535 {
+			invalid("port", "无效的端口 %q，应为 \":8080\" 的格式", c.Port)
+		}
+	}
+	if c.BotID < 0 {
+		invalid("bot_id", "不能为负数")
+	}
+	for _, id := range c.Superusers {
+		if id <= 0 {
+			invalid("superusers", "无效的QQ号 %d", id)
+		}
+	}
+	if c.ReadTimeout < 0 {
+		invalid("read_timeout", "不能为负数")
+	}
+	if c.WriteTimeout < 0 {
+		invalid("write_timeout", "不能为负数")
+	}
+	if c.APITimeout < 0 {
+		invalid("api_timeout", "不能为负数")
+	}
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		invalid("log_level", "%v", err)
+	}
+	switch strings.ToLower(c.LogFormat) {
+	case "", "text", "json":
+	default:
+		invalid("log_format", "无效的日志格式 %q，可选 text、json", c.LogFormat)
+	}
+	if c.Security.RateLimit.RequestsPerMinute < 0 {
+		invalid("security.rate_limit.requests_per_minute", "不能为负数")
+	}
+	if c.Security.RateLimit.GroupRequestsPerMinute < 0 {
+		invalid("security.rate_limit.group_requests_per_minute", "不能为负数")
+	}
+	if c.SendQueue.GlobalBurst < 0 {
+		invalid("send_queue.global_burst", "不能为负数")
+	}
+	switch c.LongMessage.Mode {
+	case api.LongMessageNone, api.LongMessageSplit, api.LongMessageForward:
+	default:
+		invalid("long_message.mode", "无效的处理方式 %q，可选 split、forward", c.LongMessage.Mode)
+	}
+	if c.LongMessage.MaxLength < 0 {
+		invalid("long_message.max_length", "不能为负数")
+	}
+	if c.Lagrange.URL != "" {
+		if u, err := url.Parse(c.Lagrange.URL); err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+			invalid("lagrange.url", "无效的地址 %q，应为 ws:// 或 wss:// 开头", c.Lagrange.URL)
+		}
+	}
+	if c.HTTP.APIURL != "" {
+		if u, err := url.Parse(c.HTTP.APIURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid("http.api_url", "无效的地址 %q，应为 http:// 或 https:// 开头", c.HTTP.APIURL)
+		}
+	}
+	if c.HTTP.EventPath != "" && !strings.HasPrefix(c.HTTP.EventPath, "/") {
+		invalid("http.event_path", "应以 / 开头")
+	}
+	if c.Lagrange.ReconnectInterval < 0 {
+		invalid("lagrange.reconnect_interval", "不能为负数")
+	}
+	if c.Lagrange.MaxReconnectInterval < 0 {
+		invalid("lagrange.max_reconnect_interval", "不能为负数")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("配置无效:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// parseConfig 解析 JSON 或纯文本格式的配置
+func parseConfig(data []byte) (map[string]interface{}, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return parsePlainConfig(data)
+	}
+
+	values := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line := bytes.Count(data[:syntaxErr.Offset], []byte("\n")) + 1
+			return nil, fmt.Errorf("第 %d 行: JSON 格式错误: %v", line, err)
+		}
+		return nil, fmt.Errorf("JSON 格式错误: %w", err)
+	}
+	return values, nil
+}
+
+// parsePlainConfig 解析每行一个 key = value 的纯文本配置
+func parsePlainConfig(data []byte) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("第 %d 行: 应为 key = value 的格式: %q", i+1, line)
+		}
+		setPath(values, strings.ToLower(key), plainValue(strings.TrimSpace(value)))
+	}
+	return values, nil
+}
+
+// applyEnv 用环境变量覆盖配置，MILONRA_ 前缀的变量优先于别名
+func applyEnv(values map[string]interface{}, environ []string) {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			env[key] = value
+		}
+	}
+
+	aliases := make([]string, 0, len(envAliases))
+	for name := range envAliases {
+		aliases = append(aliases, name)
+	}
+	sort.Strings(aliases)
+	for _, name := range aliases {
+		if value, ok := env[name]; ok && value != "" {
+			setPath(values, envAliases[name], plainValue(value))
+		}
+	}
+
+	var prefixed []string
+	for name := range env {
+		if strings.HasPrefix(name, EnvPrefix) && len(name) > len(EnvPrefix) {
+			prefixed = append(prefixed, name)
+		}
+	}
+	sort.Strings(prefixed)
+	for _, name := range prefixed {
+		path := strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(name, EnvPrefix)), "__", ".")
+		setPath(values, path, plainValue(env[name]))
+	}
+}
+
+// plainValue 推断纯文本值的类型：数字、布尔值、JSON 数组和对象按 JSON 解析，加引号或其他内容为字符串
+func plainValue(s string) interface{} {
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		return s[1 : len(s)-1]
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err == nil && !decoder.More() {
+		if _, isString := value.(string); !isString {
+			return value
+		}
+	}
+	return s
+}
+
+// setPath 按 . 分隔的路径设置嵌套的值
+func setPath(values map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := values[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			values[key] = next
+		}
+		values = next
+	}
+	values[keys[len(keys)-1]] = value
+}
+
+// decodeConfig 将解析出的值写入配置，未出现的字段保持原值
+func decodeConfig(values map[string]interface{}, config *MiloraBotConfig) error {
+	d := &configDecoder{}
+	d.convert(values, reflect.TypeOf(*config), "")
+
+	sort.Strings(d.unknown)
+	for _, key := range d.unknown {
+		logging.Logger().Warn("忽略未知的配置项", "key", key)
+	}
+	if len(d.errs) > 0 {
+		return fmt.Errorf("配置无效:\n%w", errors.Join(d.errs...))
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("配置无效: %w", err)
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return fmt.Errorf("配置无效:\n%s: 应为 %s 类型，实际为 %s", typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return fmt.Errorf("配置无效: %w", err)
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// configDecoder 按配置结构的字段类型转换解析出的值，记录无效的值和未知的字段
+type configDecoder struct {
+	errs    []error
+	unknown []string
+}
+
+// convert 将值转换为字段类型能够解析的形式：时长转为纳秒，纯文本和环境变量中的字符串转为对应类型
+func (d *configDecoder) convert(value interface{}, t reflect.Type, path string) interface{} {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == durationType {
+		return d.duration(value, path)
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		fields := jsonFields(t)
+		for key, v := range obj {
+			field, ok := fields[strings.ToLower(key)]
+			if !ok {
+				d.unknown = append(d.unknown, joinPath(path, key))
+				continue
+			}
+			obj[key] = d.convert(v, field.Type, joinPath(path, key))
+		}
+	case reflect.Slice:
+		if s, ok := value.(string); ok {
+			list := []interface{}{}
+			for _, item := range strings.Split(s, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, plainValue(item))
+				}
+			}
+			value = list
+		}
+		if list, ok := value.([]interface{}); ok {
+			for i := range list {
+				list[i] = d.convert(list[i], t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case reflect.String:
+		switch v := value.(type) {
+		case json.Number:
+			return v.String()
+		case bool:
+			return strconv.FormatBool(v)
+		}
+	case reflect.Bool:
+		if s, ok := value.(string); ok {
+			if b, err := strconv.ParseBool(s); err == nil {
+				return b
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if s, ok := value.(string); ok {
+			if _, err := strconv.ParseFloat(s, 64); err == nil {
+				return json.Number(s)
+			}
+		}
+	}
+	return value
+}
+
+// duration 将时长字符串或秒数转为纳秒
+func (d *configDecoder) duration(value interface{}, path string) interface{} {
+	var seconds float64
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return json.Number("0")
+		}
+		if duration, err := time.ParseDuration(v); err == nil {
+			return json.Number(strconv.FormatInt(int64(duration), 10))
+		}
+		s, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			d.errs = append(d.errs, fmt.Errorf("%s: 无效的时长 %q，应为 \"15s\"、\"1m30s\" 等格式", path, v))
+			return json.Number("0")
+		}
+		seconds = s
+	case json.Number:
+		s, err := v.Float64()
+		if err != nil {
+			d.errs = append(d.errs, fmt.Errorf("%s: 无效的时长 %s", path, v))
+			return json.Number("0")
+		}
+		seconds = s
+	default:
+		return value
+	}
+	return json.Number(strconv.FormatInt(int64(seconds*float64(time.Second)), 10))
+}
+
+// jsonFields 返回结构体字段的 JSON 名称（小写）到字段的映射，忽略 json:"-" 的字段
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[strings.ToLower(name)] = field
+	}
+	return fields
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,103 @@
+package sdk
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "config.json")
+	os.WriteFile(jsonPath, []byte(`{
+  "bot_id": 10001,
+  "read_timeout": "20s",
+  "plugin_timeout": 30,
+  "enable_log": false,
+  "superusers": [1, 2],
+  "security": {"rate_limit": {"enabled": true, "requests_per_minute": 10}},
+  "send_queue": {"target_interval": "500ms"}
+}`), 0644)
+
+	// 环境变量优先于配置文件
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("API_PORT", "9000")
+	t.Setenv("MILONRA_SECURITY__RATE_LIMIT__REQUESTS_PER_MINUTE", "30")
+
+	config, err := LoadConfig(jsonPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if config.BotID != 10001 || config.ReadTimeout != 20*time.Second || config.PluginTimeout != 30*time.Second {
+		t.Errorf("Unexpected values from file: %+v", config)
+	}
+	if config.WriteTimeout != 15*time.Second || config.DataDir != "./data" {
+		t.Errorf("Expected defaults for unset fields, got write_timeout=%v data_dir=%q", config.WriteTimeout, config.DataDir)
+	}
+	if config.SendQueue.Enabled || config.LongMessage.Mode != "" || !config.AutoLoadPlugins {
+		t.Errorf("Expected unset switches to keep their defaults, got send_queue=%v long_message=%q auto_load_plugins=%v",
+			config.SendQueue.Enabled, config.LongMessage.Mode, config.AutoLoadPlugins)
+	}
+	if config.EnableLog || len(config.Superusers) != 2 || config.SendQueue.TargetInterval != 500*time.Millisecond {
+		t.Errorf("Unexpected values from file: %+v", config)
+	}
+	if config.LogLevel != "debug" || config.Port != ":9000" || config.Security.RateLimit.RequestsPerMinute != 30 {
+		t.Errorf("Expected environment overrides, got log_level=%q port=%q rpm=%d",
+			config.LogLevel, config.Port, config.Security.RateLimit.RequestsPerMinute)
+	}
+
+	// 示例配置可以直接加载，框架不使用的数据库配置原样保留
+	config, err = LoadConfig(filepath.Join("..", "config.example.json"))
+	if err != nil {
+		t.Fatalf("LoadConfig(config.example.json) failed: %v", err)
+	}
+	var database struct{ Type, Path string }
+	if err := json.Unmarshal(config.Database, &database); err != nil || database.Type != "sqlite" || database.Path != "./data/bot.db" {
+		t.Errorf("Unexpected database config: %s (%v)", config.Database, err)
+	}
+	if len(config.Security.AllowedOrigins) != 1 || config.Security.AllowedOrigins[0] != "*" {
+		t.Errorf("Unexpected allowed_origins: %v", config.Security.AllowedOrigins)
+	}
+
+	// 纯文本格式
+	plainPath := filepath.Join(dir, "config.conf")
+	os.WriteFile(plainPath, []byte("# 注释\nbot_id = 20002\nsuperusers = 3, 4\nlagrange.url = ws://localhost:8081\nlagrange.reconnect_interval = 5s\n"), 0644)
+	config, err = LoadConfig(plainPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if config.BotID != 20002 || len(config.Superusers) != 2 || config.Superusers[1] != 4 ||
+		config.Lagrange.URL != "ws://localhost:8081" || config.Lagrange.ReconnectInterval != 5*time.Second {
+		t.Errorf("Unexpected values from plain config: %+v", config)
+	}
+
+	// 无效的配置返回包含字段名的错误
+	t.Setenv("LOG_LEVEL", "")
+	os.WriteFile(jsonPath, []byte(`{"read_timeout": "15x", "log_level": "verbose"}`), 0644)
+	if _, err := LoadConfig(jsonPath); err == nil || !strings.Contains(err.Error(), "read_timeout") {
+		t.Errorf("Expected read_timeout error, got %v", err)
+	}
+	os.WriteFile(jsonPath, []byte(`{"log_level": "verbose", "bot_id": "abc"}`), 0644)
+	if _, err := LoadConfig(jsonPath); err == nil || !strings.Contains(err.Error(), "bot_id") {
+		t.Errorf("Expected bot_id error, got %v", err)
+	}
+	os.WriteFile(jsonPath, []byte(`{"superusers": [1]}`), 0644)
+	if _, err := LoadConfig(jsonPath); err == nil || !strings.Contains(err.Error(), "bot_id") {
+		t.Errorf("Expected missing bot_id error, got %v", err)
+	}
+	os.WriteFile(jsonPath, []byte(`{"log_level": "verbose"}`), 0644)
+	if _, err := LoadConfig(jsonPath); err == nil || !strings.Contains(err.Error(), "log_level") {
+		t.Errorf("Expected log_level error, got %v", err)
+	}
+	os.WriteFile(jsonPath, []byte(`{"log_format": "xml"}`), 0644)
+	if _, err := LoadConfig(jsonPath); err == nil || !strings.Contains(err.Error(), "log_format") {
+		t.Errorf("Expected log_format error, got %v", err)
+	}
+	os.WriteFile(jsonPath, []byte("{\n  \"bot_id\": 1,\n}"), 0644)
+	if _, err := LoadConfig(jsonPath); err == nil || !strings.Contains(err.Error(), "第 3 行") {
+		t.Errorf("Expected syntax error with line number, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package sdk
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/iamlibie/milonra-go/bot"
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/logging"
+)
+
+// connHealth 连接健康状态，由生命周期和心跳元事件维护
+type connHealth struct {
+	mu            sync.RWMutex
+	connected     bool
+	healthy       bool
+	lastHeartbeat time.Time
+	interval      time.Duration
+}
+
+// healthSnapshot 连接健康状态快照
+type healthSnapshot struct {
+	Connected     bool
+	Healthy       bool
+	LastHeartbeat time.Time
+	Interval      time.Duration
+}
+
+// onConnect 新连接建立时重置状态
+func (h *connHealth) onConnect() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.connected = true
+	h.healthy = true
+	h.lastHeartbeat = time.Time{}
+	h.interval = 0
+}
+
+// onDisconnect 连接断开时记录状态，健康标记保留到下一次连接建立
+func (h *connHealth) onDisconnect() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.connected = false
+}
+
+// onMeta 根据元事件更新心跳状态
+func (h *connHealth) onMeta(e event.Meta) {
+	heartbeat, ok := e.(*event.HeartbeatEvent)
+	if !ok {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastHeartbeat = time.Now()
+	h.healthy = true
+	if heartbeat.Interval > 0 {
+		h.interval = time.Duration(heartbeat.Interval) * time.Millisecond
+	}
+}
+
+// expired 检查心跳是否已经连续 maxMissed 个周期未到达，超时则标记为不健康
+// 在收到第一个携带间隔的心跳之前不做判断
+func (h *connHealth) expired(maxMissed int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.connected || h.interval == 0 || h.lastHeartbeat.IsZero() {
+		return false
+	}
+	if time.Since(h.lastHeartbeat) <= h.interval*time.Duration(maxMissed) {
+		return false
+	}
+	h.healthy = false
+	return true
+}
+
+// snapshot 获取当前健康状态
+func (h *connHealth) snapshot() healthSnapshot {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return healthSnapshot{
+		Connected:     h.connected,
+		Healthy:       h.healthy,
+		LastHeartbeat: h.lastHeartbeat,
+		Interval:      h.interval,
+	}
+}
+
+// HealthHandler 返回健康检查的 HTTP 处理器，有账号在线且所有账号的连接健康时返回 200，
+// 否则返回 503（没有账号在线或心跳超时后为不健康）
+func (mb *MiloraBot) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !mb.IsHealthy() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("UNHEALTHY"))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+}
+
+// watchHeartbeat 监控账号连接的心跳，超时后关闭连接，等待 OneBot 实现反向重连或由正向连接自动重连
+func (mb *MiloraBot) watchHeartbeat(conn *websocket.Conn, b *bot.Bot, health *connHealth, done <-chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-mb.ctx.Done():
+			return
+		case <-ticker.C:
+			if missed := mb.currentConfig().MaxMissedHeartbeats; health.expired(missed) {
+				logging.Logger().Warn("连续多个心跳周期未收到心跳，关闭连接等待重连",
+					logging.KeySelfID, b.GetSelfID(), "missed", missed)
+				conn.Close()
+				return
+			}
+		}
+	}
+}
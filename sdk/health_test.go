@@ -0,0 +1,106 @@
+package sdk
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Test the connection is closed and /health reports 503 once heartbeats stop arriving
+func TestHeartbeatWatchdog(t *testing.T) {
+	mb := NewMiloraBot(&MiloraBotConfig{BotID: 10001, DataDir: t.TempDir(), MaxMissedHeartbeats: 2})
+	server := httptest.NewServer(mb.WebSocketHandler())
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/"
+
+	health := func() int {
+		rec := httptest.NewRecorder()
+		mb.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		return rec.Code
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"X-Self-ID": {"10001"}})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	// 只发送一次心跳，之后不再发送
+	conn.WriteJSON(map[string]interface{}{
+		"post_type": "meta_event", "meta_event_type": "heartbeat", "self_id": float64(10001),
+		"interval": float64(100), "status": map[string]interface{}{"online": true, "good": true},
+		"time": float64(time.Now().Unix()),
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for mb.GetBot(10001) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected bot 10001 to be online")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if code := health(); code != http.StatusOK {
+		t.Errorf("Expected /health to return 200 while heartbeats arrive, got %d", code)
+	}
+
+	// 连续 MaxMissedHeartbeats 个周期未收到心跳后服务端关闭连接
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				t.Fatal("Expected the connection to be closed after missed heartbeats")
+			}
+			break
+		}
+	}
+
+	if code := health(); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected /health to return 503 after missed heartbeats, got %d", code)
+	}
+	if mb.IsHealthy() {
+		t.Error("Expected bot to be unhealthy after missed heartbeats")
+	}
+}
+
+// Test /health reports 503 without any connected account and disconnected accounts are removed
+func TestHealthWithoutBots(t *testing.T) {
+	mb := NewMiloraBot(&MiloraBotConfig{BotID: 10001, DataDir: t.TempDir()})
+	server := httptest.NewServer(mb.WebSocketHandler())
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/"
+
+	if mb.IsHealthy() {
+		t.Error("Expected bot to be unhealthy before any account connects")
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"X-Self-ID": {"10001"}})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for mb.GetBot(10001) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected bot 10001 to be online")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !mb.IsHealthy() {
+		t.Error("Expected bot to be healthy while connected")
+	}
+
+	conn.Close()
+	deadline = time.Now().Add(2 * time.Second)
+	for len(mb.BotStatuses()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the disconnected account to be removed, got %+v", mb.BotStatuses())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if mb.IsHealthy() {
+		t.Error("Expected bot to be unhealthy after the only account disconnected")
+	}
+}
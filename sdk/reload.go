@@ -0,0 +1,220 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/iamlibie/milonra-go/api"
+	"github.com/iamlibie/milonra-go/filter"
+	"github.com/iamlibie/milonra-go/logging"
+	mplugin "github.com/iamlibie/milonra-go/plugin"
+	"github.com/iamlibie/milonra-go/ratelimit"
+)
+
+// ErrNoConfigFile 配置不是通过 LoadConfig 从文件加载的，无法重新加载
+var ErrNoConfigFile = errors.New("配置不是通过 LoadConfig 从文件加载的，无法重新加载")
+
+// Reload 重新加载配置文件和环境变量，校验通过后立即应用可以热更新的配置并通知插件（见 OnReload）
+//
+// 黑白名单、启用的插件、超级用户、日志、API 超时、限流、发送队列和超长消息等配置立即生效；
+// bot_id 和 plugin_timeout 在 OneBot 实现重新连接后生效；
+// 监听地址、超时、目录等需要重启才能生效的修改会被忽略并输出警告。
+// 配置无效时返回错误，原配置保持不变。
+func (mb *MiloraBot) Reload() error {
+	path, err := mb.reloadConfig()
+	if err != nil {
+		return err
+	}
+
+	// 通知插件时不持有 reloadMu，插件可以在回调中调用 Set* 等修改配置的方法
+	for name, err := range mplugin.NotifyReload() {
+		logging.Logger().Error("插件应用新配置失败", logging.KeyPlugin, name, logging.KeyError, err)
+	}
+	logging.Logger().Info("已重新加载配置", "path", path)
+	return nil
+}
+
+// reloadConfig 重新读取配置文件并应用，返回配置文件的路径
+func (mb *MiloraBot) reloadConfig() (string, error) {
+	mb.reloadMu.Lock()
+	defer mb.reloadMu.Unlock()
+
+	path := mb.currentConfig().path
+	if path == "" {
+		return "", ErrNoConfigFile
+	}
+	next, err := LoadConfig(path)
+	if err != nil {
+		logging.Logger().Error("重新加载配置失败，继续使用原配置", logging.KeyError, err)
+		return "", err
+	}
+	next.setDefaults()
+	mb.applyConfig(next)
+	return path, nil
+}
+
+// OnReload 注册配置重新加载后调用的函数，fn 收到的是已经应用的新配置
+func (mb *MiloraBot) OnReload(name string, fn func(config *MiloraBotConfig) error) {
+	mplugin.OnReload(name, func() error {
+		return fn(mb.currentConfig())
+	})
+}
+
+// applyConfig 应用新配置中可以热更新的字段，调用前需持有 reloadMu
+//
+// 修改的是当前配置的副本，应用完成后整体替换，读取配置的连接和处理器不需要加锁
+func (mb *MiloraBot) applyConfig(next *MiloraBotConfig) {
+	updated := *mb.currentConfig()
+	c := &updated
+
+	// 需要重启才能生效的配置
+	restart := []struct {
+		name    string
+		changed bool
+	}{
+		{"port", c.Port != next.Port},
+		{"host", c.Host != next.Host},
+		{"read_timeout", c.ReadTimeout != next.ReadTimeout},
+		{"write_timeout", c.WriteTimeout != next.WriteTimeout},
+		{"plugin_dir", c.PluginDir != next.PluginDir},
+		{"plugin_file_pattern", c.PluginFilePattern != next.PluginFilePattern},
+		{"auto_load_plugins", c.AutoLoadPlugins != next.AutoLoadPlugins},
+		{"data_dir", c.DataDir != next.DataDir},
+		{"lagrange", c.Lagrange != next.Lagrange},
+		{"http", c.HTTP != next.HTTP},
+		{"config_watch_interval", c.ConfigWatchInterval != next.ConfigWatchInterval},
+	}
+	for _, field := range restart {
+		if field.changed {
+			logging.Logger().Warn("修改的配置需要重启才能生效，已忽略", "key", field.name)
+		}
+	}
+
+	// OneBot 实现重新连接后生效
+	if c.BotID != next.BotID || c.PluginTimeout != next.PluginTimeout {
+		logging.Logger().Info("bot_id 和 plugin_timeout 的修改将在 OneBot 实现重新连接后生效")
+	}
+	c.BotID = next.BotID
+	c.PluginTimeout = next.PluginTimeout
+
+	// 立即生效
+	if c.EnableLog != next.EnableLog || c.LogLevel != next.LogLevel || c.LogFormat != next.LogFormat {
+		c.EnableLog, c.LogLevel, c.LogFormat = next.EnableLog, next.LogLevel, next.LogFormat
+		c.configureLogging()
+	}
+
+	c.Superusers = next.Superusers
+	mplugin.SetSuperusers(c.Superusers...)
+
+	if !reflect.DeepEqual(c.EnabledPlugins, next.EnabledPlugins) {
+		c.EnabledPlugins = next.EnabledPlugins
+		mplugin.SetEnabledPlugins(c.EnabledPlugins)
+	}
+
+	c.AllowUsers, c.DenyUsers = next.AllowUsers, next.DenyUsers
+	c.AllowGroups, c.DenyGroups = next.AllowGroups, next.DenyGroups
+	filter.SetBase(c.filterLists())
+
+	c.MaxPluginPanics = next.MaxPluginPanics
+	mplugin.SetMaxPanics(c.MaxPluginPanics)
+	c.MaxMissedHeartbeats = next.MaxMissedHeartbeats
+	c.APITimeout = next.APITimeout
+	api.SetDefaultTimeout(c.APITimeout)
+
+	if !reflect.DeepEqual(c.Security, next.Security) {
+		c.Security = next.Security
+		ratelimit.Configure(c.Security.RateLimit)
+	}
+	if c.SendQueue != next.SendQueue {
+		c.SendQueue = next.SendQueue
+		api.ConfigureSendQueue(c.SendQueue)
+	}
+	if c.LongMessage != next.LongMessage {
+		c.LongMessage = next.LongMessage
+		api.ConfigureLongMessage(c.LongMessage)
+	}
+	c.Database = next.Database
+
+	mb.config.Store(c)
+}
+
+// watchConfig 定期检查配置文件，文件被修改后重新加载，直到 ctx 取消
+func (mb *MiloraBot) watchConfig(ctx context.Context) {
+	config := mb.currentConfig()
+	interval, path := config.ConfigWatchInterval, config.path
+	if path == "" || interval < 0 {
+		return
+	}
+
+	last := fileModTime(path)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := fileModTime(path)
+		if current.IsZero() || current.Equal(last) {
+			continue
+		}
+		last = current
+		mb.Reload()
+	}
+}
+
+// watchSignal 收到 SIGHUP 时重新加载配置，直到 ctx 取消
+func (mb *MiloraBot) watchSignal(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			if err := mb.Reload(); errors.Is(err, ErrNoConfigFile) {
+				logging.Logger().Warn("收到 SIGHUP，但无法重新加载配置", logging.KeyError, err)
+			}
+		}
+	}
+}
+
+// handleReload 管理端点：POST /reload 重新加载配置，只接受来自本机的请求
+func (mb *MiloraBot) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if ip := net.ParseIP(host); err != nil || ip == nil || !ip.IsLoopback() {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	if err := mb.Reload(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Write([]byte("OK"))
+}
+
+func fileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
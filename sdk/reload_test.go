@@ -0,0 +1,143 @@
+package sdk
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iamlibie/milonra-go/api"
+	"github.com/iamlibie/milonra-go/filter"
+	mplugin "github.com/iamlibie/milonra-go/plugin"
+	"github.com/iamlibie/milonra-go/ratelimit"
+)
+
+// Test reload hooks can change the configuration through the Set* methods
+func TestReloadHookSetsConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	content := `{"bot_id": 10001, "data_dir": "` + filepath.ToSlash(dir) + `"}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	mb := NewMiloraBot(config)
+
+	mb.OnReload("reload_hook_test", func(config *MiloraBotConfig) error {
+		mb.SetSuperusers(42)
+		return nil
+	})
+	t.Cleanup(func() {
+		mplugin.RemoveReloadHook("reload_hook_test")
+		mplugin.SetSuperusers()
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- mb.Reload() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Reload failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Reload deadlocked when a hook changed the configuration")
+	}
+	if got := mb.GetConfig().Superusers; len(got) != 1 || got[0] != 42 {
+		t.Errorf("Expected superusers set by the hook, got %v", got)
+	}
+}
+
+func TestConfigReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	write(`{"bot_id": 10001, "port": ":18080", "data_dir": "` + dir + `", "deny_users": [5]}`)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	mb := NewMiloraBot(config)
+	defer func() {
+		filter.SetBase(filter.Lists{})
+		filter.Load(filepath.Join(t.TempDir(), "empty.json"))
+		mplugin.LoadSwitches(filepath.Join(t.TempDir(), "empty.json"))
+		mplugin.LoadRoles(filepath.Join(t.TempDir(), "empty.json"))
+		mplugin.SetSuperusers()
+		ratelimit.Configure(ratelimit.Config{})
+		api.ConfigureSendQueue(api.SendQueueConfig{})
+		api.ConfigureLongMessage(api.LongMessageConfig{})
+	}()
+
+	reloads := 0
+	mb.OnReload("reload_test", func(config *MiloraBotConfig) error {
+		reloads++
+		return nil
+	})
+	defer mplugin.RemoveReloadHook("reload_test")
+
+	if filter.Allowed(0, 5) || !filter.Allowed(0, 6) {
+		t.Fatal("Expected user 5 to be denied before reload")
+	}
+
+	// 黑名单立即生效，端口的修改被忽略
+	write(`{"bot_id": 10001, "port": ":19090", "data_dir": "` + dir + `", "deny_users": [6], "superusers": [7]}`)
+	if err := mb.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if !filter.Allowed(0, 5) || filter.Allowed(0, 6) {
+		t.Error("Expected deny list to be replaced after reload")
+	}
+	if !mplugin.IsSuperuser(7) {
+		t.Error("Expected superusers to be reloaded")
+	}
+	if mb.GetConfig().Port != ":18080" {
+		t.Errorf("Expected port change to be ignored, got %s", mb.GetConfig().Port)
+	}
+	if reloads != 1 {
+		t.Errorf("Expected reload hook to be called once, got %d", reloads)
+	}
+
+	// 无效的配置不会被应用
+	write(`{"log_level": "verbose", "deny_users": []}`)
+	if err := mb.Reload(); err == nil {
+		t.Error("Expected invalid config to be rejected")
+	}
+	if filter.Allowed(0, 6) || reloads != 1 {
+		t.Error("Expected previous config to stay in effect")
+	}
+
+	// 重新加载的同时处理连接（配合 -race 检查并发读取配置）
+	handler := mb.WebSocketHandler()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			write(fmt.Sprintf(`{"bot_id": 10001, "data_dir": %q, "security": {"access_token": "secret-%d"}}`, dir, i))
+			mb.Reload()
+		}
+	}()
+	for serving := true; serving; {
+		select {
+		case <-done:
+			serving = false
+		default:
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("Expected reloaded access token to be required, got %d", recorder.Code)
+	}
+}
@@ -3,19 +3,27 @@ package sdk
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 	"plugin"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 
+	"github.com/iamlibie/milonra-go/api"
 	"github.com/iamlibie/milonra-go/bot"
 	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/filter"
+	"github.com/iamlibie/milonra-go/logging"
 	mplugin "github.com/iamlibie/milonra-go/plugin"
+	"github.com/iamlibie/milonra-go/ratelimit"
 )
 
 // Bot 接口，包装核心plugin.Bot接口
@@ -27,6 +35,74 @@ type Bot interface {
 // PluginFunc 插件函数类型：输入bot实例和消息事件，输出回复
 type PluginFunc func(bot Bot, event *event.MessageEvent) string
 
+// HandlerFunc 支持上下文的插件函数类型：ctx 会在插件超时、连接断开或服务停止时取消
+type HandlerFunc func(ctx context.Context, bot Bot, event *event.MessageEvent) *mplugin.Reply
+
+// ReplyFunc 富消息插件函数类型：输入bot实例和消息事件，输出回复（nil 表示不回复）
+type ReplyFunc func(bot Bot, event *event.MessageEvent) *mplugin.Reply
+
+// MatchFunc 声明式匹配插件函数类型：只有消息满足注册时声明的规则才会被调用，m 为解析出的参数
+type MatchFunc func(ctx context.Context, bot Bot, event *event.MessageEvent, m *mplugin.Match) *mplugin.Reply
+
+// NoticeFunc 通知插件函数类型：输入bot实例和通知事件，输出回复
+type NoticeFunc func(bot Bot, event event.Notice) string
+
+// MetaFunc 元事件插件函数类型：输入bot实例和元事件（生命周期、心跳）
+type MetaFunc func(bot Bot, event event.Meta)
+
+// RequestFunc 请求插件函数类型：输入bot实例和请求事件，输出处理结果（nil 表示不处理）
+type RequestFunc func(bot Bot, event *event.RequestEvent) *mplugin.RequestReply
+
+// PluginOption 消息插件的注册选项（优先级、阻止传播、超时）
+type PluginOption = mplugin.Option
+
+// 命令定义相关类型，详见 plugin.RegisterCommands
+type (
+	CommandSpec = mplugin.CommandSpec
+	ArgSpec     = mplugin.ArgSpec
+	FlagSpec    = mplugin.FlagSpec
+	CommandArgs = mplugin.Args
+)
+
+// 常用的注册选项，详见 plugin 包
+var (
+	WithPriority   = mplugin.WithPriority
+	WithBlock      = mplugin.WithBlock
+	WithTimeout    = mplugin.WithTimeout
+	WithPrefixes   = mplugin.WithPrefixes
+	WithRule       = mplugin.WithRule
+	Command        = mplugin.Command
+	Regex          = mplugin.Regex
+	Keyword        = mplugin.Keyword
+	OnlyToMe       = mplugin.OnlyToMe
+	GroupOnly      = mplugin.GroupOnly
+	PrivateOnly    = mplugin.PrivateOnly
+	WithPermission = mplugin.WithPermission
+	WithRole       = mplugin.WithRole
+	WithRateLimit  = mplugin.WithRateLimit
+	WithCooldown   = mplugin.WithCooldown
+)
+
+// 多账号：按账号获取在线的 Bot，或切换发送消息的账号，详见 plugin.SwitchBot
+var (
+	GetOnlineBot = mplugin.GetBot
+	SwitchBot    = mplugin.SwitchBot
+)
+
+// 插件日志，详见 plugin.LoggerOf
+var (
+	LoggerOf     = mplugin.LoggerOf
+	PluginLogger = mplugin.Logger
+)
+
+// 权限等级，详见 plugin.Permission
+const (
+	PermMember    = mplugin.PermMember
+	PermAdmin     = mplugin.PermAdmin
+	PermOwner     = mplugin.PermOwner
+	PermSuperuser = mplugin.PermSuperuser
+)
+
 // botAdapter 适配器，将sdk.Bot转换为plugin.Bot
 type botAdapter struct {
 	inner Bot
@@ -40,6 +116,16 @@ func (ba *botAdapter) GetSelfID() int64 {
 	return ba.inner.GetSelfID()
 }
 
+// Context 返回被包装 Bot 绑定的上下文，使 API 调用和 plugin.ContextOf 能感知取消
+func (ba *botAdapter) Context() context.Context {
+	return mplugin.ContextOf(ba.inner)
+}
+
+// Unwrap 返回被包装的 Bot，使 API 调用使用其连接的响应等待器
+func (ba *botAdapter) Unwrap() mplugin.Bot {
+	return ba.inner
+}
+
 // MiloraBotConfig SDK配置结构
 type MiloraBotConfig struct {
 	// 服务配置
@@ -49,30 +135,168 @@ type MiloraBotConfig struct {
 	WriteTimeout time.Duration `json:"write_timeout"` // 写入超时，默认 15秒
 
 	// 机器人配置
-	BotID int64 `json:"bot_id"` // 机器人QQ号
+	BotID      int64   `json:"bot_id"`     // 机器人QQ号
+	Superusers []int64 `json:"superusers"` // 超级用户QQ号，拥有所有权限
 
 	// WebSocket配置
-	CheckOrigin func(*http.Request) bool `json:"-"` // 跨域检查函数
+	CheckOrigin func(*http.Request) bool `json:"-"` // 跨域检查函数，设置后代替 security.allowed_origins
 
 	// 日志配置
-	EnableLog bool   `json:"enable_log"` // 是否启用日志，默认 true
-	LogLevel  string `json:"log_level"`  // 日志级别：debug, info, warn, error
+	EnableLog bool         `json:"enable_log"` // 是否启用日志，默认 true
+	LogLevel  string       `json:"log_level"`  // 日志级别：debug, info, warn, error，默认 info
+	LogFormat string       `json:"log_format"` // 日志格式：text, json，默认 text
+	Logger    *slog.Logger `json:"-"`          // 自定义日志记录器，设置后忽略 LogLevel 和 LogFormat
 
 	// 插件配置
-	PluginDir         string   `json:"plugin_dir"`          // 插件目录，默认 "./plugins"
-	EnabledPlugins    []string `json:"enabled_plugins"`     // 启用的插件列表，空表示全部启用
-	AutoLoadPlugins   bool     `json:"auto_load_plugins"`   // 是否自动加载插件目录中的插件
-	PluginFilePattern string   `json:"plugin_file_pattern"` // 插件文件匹配模式，默认 "*.so"
+	PluginDir         string        `json:"plugin_dir"`          // 插件目录，默认 "./plugins"
+	EnabledPlugins    []string      `json:"enabled_plugins"`     // 启用的插件列表，空表示全部启用
+	AutoLoadPlugins   bool          `json:"auto_load_plugins"`   // 是否自动加载插件目录中的插件
+	PluginFilePattern string        `json:"plugin_file_pattern"` // 插件文件匹配模式，默认 "*.so"
+	PluginTimeout     time.Duration `json:"plugin_timeout"`      // 单次插件调用的超时时间，默认 60秒，负数表示不限制
+	MaxPluginPanics   int           `json:"max_plugin_panics"`   // 插件连续 panic 多少次后自动禁用，默认 5，负数表示不自动禁用
+	APITimeout        time.Duration `json:"api_timeout"`         // API 调用（发送请求和等待响应）的默认超时时间，默认 30秒，单次调用可用 api.WithTimeout 覆盖
+
+	// 过滤配置（黑白名单），超级用户不受限制
+	AllowUsers  []int64 `json:"allow_users"`  // 用户白名单，不为空时只处理这些用户的事件
+	DenyUsers   []int64 `json:"deny_users"`   // 用户黑名单
+	AllowGroups []int64 `json:"allow_groups"` // 群白名单，不为空时只处理这些群的事件
+	DenyGroups  []int64 `json:"deny_groups"`  // 群黑名单
+
+	// 安全配置
+	Security SecurityConfig `json:"security"`
+
+	// 发送配置：限制发送频率，避免短时间内大量回复触发平台风控
+	SendQueue   api.SendQueueConfig   `json:"send_queue"`
+	LongMessage api.LongMessageConfig `json:"long_message"` // 超长消息自动拆分或合并转发
+
+	// 数据配置
+	DataDir  string          `json:"data_dir"`           // 数据目录，保存插件开关等运行时状态，默认 "./data"
+	Database json.RawMessage `json:"database,omitempty"` // 插件使用的数据库配置，框架不解析，原样保留供插件通过 GetConfig 读取
+
+	// 心跳配置
+	MaxMissedHeartbeats int `json:"max_missed_heartbeats"` // 连续多少个心跳周期未收到心跳即判定连接失效，默认 3
+
+	// OneBot 实现配置
+	Lagrange LagrangeConfig `json:"lagrange"`
+	HTTP     HTTPConfig     `json:"http"` // 通过 HTTP 而不是 WebSocket 与 OneBot 实现通信
+
+	// 热更新配置：修改 LoadConfig 加载的配置文件后自动重新加载
+	ConfigWatchInterval time.Duration `json:"config_watch_interval"` // 检查配置文件是否修改的间隔，默认 5秒，负数表示不自动检查
+
+	loaded bool   // 由 LoadConfig 加载，布尔字段以配置为准
+	path   string // LoadConfig 读取的配置文件，用于重新加载
+}
+
+// SecurityConfig 安全配置
+type SecurityConfig struct {
+	AccessToken    string           `json:"access_token"`    // 反向 WebSocket 的访问令牌，不为空时拒绝未携带正确令牌的连接
+	AllowedOrigins []string         `json:"allowed_origins"` // 允许连接的 Origin，"*" 表示所有；为空时只允许同源和不带 Origin 的请求
+	RateLimit      ratelimit.Config `json:"rate_limit"`      // 触发插件的频率限制，超级用户不受限制
+}
+
+// setDefaults 为未设置的字段填充默认值
+func (c *MiloraBotConfig) setDefaults() {
+	if c.Port == "" {
+		c.Port = ":8080"
+	}
+
+	if c.ReadTimeout == 0 {
+		c.ReadTimeout = 15 * time.Second
+	}
+
+	if c.WriteTimeout == 0 {
+		c.WriteTimeout = 15 * time.Second
+	}
+
+	if c.LogLevel == "" {
+		c.LogLevel = "info"
+	}
+
+	if c.PluginDir == "" {
+		c.PluginDir = "./plugins"
+	}
+
+	if c.PluginFilePattern == "" {
+		c.PluginFilePattern = "*.so"
+	}
+
+	if c.PluginTimeout == 0 {
+		c.PluginTimeout = 60 * time.Second
+	}
+
+	if c.MaxPluginPanics == 0 {
+		c.MaxPluginPanics = 5
+	}
+
+	if c.APITimeout == 0 {
+		c.APITimeout = 30 * time.Second
+	}
+
+	if c.MaxMissedHeartbeats <= 0 {
+		c.MaxMissedHeartbeats = 3
+	}
+
+	if c.DataDir == "" {
+		c.DataDir = "./data"
+	}
+
+	if c.Lagrange.ReconnectInterval == 0 {
+		c.Lagrange.ReconnectInterval = 5 * time.Second
+	}
+
+	if c.Lagrange.MaxReconnectInterval == 0 {
+		c.Lagrange.MaxReconnectInterval = time.Minute
+	}
+
+	if c.HTTP.EventPath == "" {
+		c.HTTP.EventPath = "/onebot/event"
+	}
+
+	if c.HTTP.QuickReplyTimeout == 0 {
+		c.HTTP.QuickReplyTimeout = 500 * time.Millisecond
+	}
+
+	if c.ConfigWatchInterval == 0 {
+		c.ConfigWatchInterval = 5 * time.Second
+	}
+}
+
+// configureLogging 按配置设置框架的日志记录器
+func (c *MiloraBotConfig) configureLogging() {
+	switch {
+	case !c.EnableLog:
+		logging.SetLogger(slog.New(slog.DiscardHandler))
+	case c.Logger != nil:
+		logging.SetLogger(c.Logger)
+	default:
+		if err := logging.Configure(os.Stderr, c.LogLevel, c.LogFormat); err != nil {
+			logging.Configure(os.Stderr, "info", "text")
+			logging.Logger().Warn("日志配置无效，使用默认配置", logging.KeyError, err)
+		}
+	}
+}
+
+// filterLists 返回配置中的黑白名单
+func (c *MiloraBotConfig) filterLists() filter.Lists {
+	return filter.Lists{
+		AllowUsers:  c.AllowUsers,
+		DenyUsers:   c.DenyUsers,
+		AllowGroups: c.AllowGroups,
+		DenyGroups:  c.DenyGroups,
+	}
 }
 
 // MiloraBot SDK主结构
 type MiloraBot struct {
-	config   *MiloraBotConfig
+	config   atomic.Pointer[MiloraBotConfig] // 当前配置，修改时整体替换（见 updateConfig），读取时不需要加锁
 	server   *http.Server
 	upgrader websocket.Upgrader
-	bot      *bot.Bot
+	bots     map[int64]*botConn // 按账号记录的连接
+	botsMu   sync.RWMutex
+	rejected authStats
 	ctx      context.Context
 	cancel   context.CancelFunc
+	reloadMu sync.Mutex
 }
 
 // NewMiloraBot 创建新的MiloraBot实例
@@ -82,51 +306,60 @@ func NewMiloraBot(config *MiloraBotConfig) *MiloraBot {
 		config = &MiloraBotConfig{}
 	}
 
-	if config.Port == "" {
-		config.Port = ":8080"
-	}
-
-	if config.ReadTimeout == 0 {
-		config.ReadTimeout = 15 * time.Second
-	}
+	config.setDefaults()
 
-	if config.WriteTimeout == 0 {
-		config.WriteTimeout = 15 * time.Second
+	// 代码中创建的配置默认启用自动加载插件和日志，LoadConfig 加载的配置以配置文件为准
+	if !config.loaded {
+		config.AutoLoadPlugins = true
+		config.EnableLog = true
 	}
+	config.configureLogging()
 
-	if config.CheckOrigin == nil {
-		config.CheckOrigin = func(r *http.Request) bool { return true }
-	}
+	mplugin.SetMaxPanics(config.MaxPluginPanics)
 
-	if config.LogLevel == "" {
-		config.LogLevel = "info"
+	// 插件开关：配置中的启用列表作为默认值，运行时的修改保存在数据目录中
+	mplugin.SetEnabledPlugins(config.EnabledPlugins)
+	if err := mplugin.LoadSwitches(filepath.Join(config.DataDir, "plugin_switches.json")); err != nil {
+		logging.Logger().Warn("加载插件开关失败", logging.KeyError, err)
 	}
+	mplugin.RegisterSwitchCommands()
 
-	if config.PluginDir == "" {
-		config.PluginDir = "./plugins"
+	// 权限：超级用户来自配置，自定义角色保存在数据目录中
+	mplugin.SetSuperusers(config.Superusers...)
+	if err := mplugin.LoadRoles(filepath.Join(config.DataDir, "roles.json")); err != nil {
+		logging.Logger().Warn("加载角色失败", logging.KeyError, err)
 	}
+	mplugin.RegisterRoleCommands()
 
-	if config.PluginFilePattern == "" {
-		config.PluginFilePattern = "*.so"
+	// 黑白名单：配置中的名单与运行时添加的名单同时生效
+	filter.SetBase(config.filterLists())
+	if err := filter.Load(filepath.Join(config.DataDir, "filter.json")); err != nil {
+		logging.Logger().Warn("加载黑白名单失败", logging.KeyError, err)
 	}
+	filter.RegisterCommands()
 
-	// 默认启用自动加载插件
-	config.AutoLoadPlugins = true
+	// 全局限流
+	ratelimit.Configure(config.Security.RateLimit)
 
-	// 默认启用日志
-	config.EnableLog = true
+	// 发送队列、超长消息处理和 API 响应超时
+	api.ConfigureSendQueue(config.SendQueue)
+	api.ConfigureLongMessage(config.LongMessage)
+	api.SetDefaultTimeout(config.APITimeout)
 
 	ctx, cancel := context.WithCancel(context.Background())
 
 	mb := &MiloraBot{
-		config: config,
 		upgrader: websocket.Upgrader{
-			CheckOrigin: config.CheckOrigin,
+			// Origin 已经在 authorize 中检查
+			CheckOrigin: func(r *http.Request) bool { return true },
 		},
+		bots:   make(map[int64]*botConn),
 		ctx:    ctx,
 		cancel: cancel,
 	}
 
+	mb.config.Store(config)
+
 	// 创建HTTP服务器
 	mb.server = &http.Server{
 		Addr:         config.Port,
@@ -137,44 +370,172 @@ func NewMiloraBot(config *MiloraBotConfig) *MiloraBot {
 	return mb
 }
 
-// RegisterPlugin 注册插件
-func (mb *MiloraBot) RegisterPlugin(name string, pluginFunc PluginFunc) {
+// RegisterPlugin 注册插件，可通过 opts 设置优先级、阻止传播和超时
+func (mb *MiloraBot) RegisterPlugin(name string, pluginFunc PluginFunc, opts ...PluginOption) {
 	// 将SDK插件包装为核心plugin包的插件
 	wrappedFunc := func(bot mplugin.Bot, e *event.MessageEvent) string {
 		return pluginFunc(&botAdapter{bot}, e)
 	}
-	mplugin.Register(name, wrappedFunc)
-	if mb.config.EnableLog {
-		log.Printf("🔌 插件已注册: %s", name)
+	mplugin.Register(name, wrappedFunc, opts...)
+}
+
+// RegisterReplyPlugin 注册富消息插件，插件可以回复图片、@、引用回复或多条消息
+func (mb *MiloraBot) RegisterReplyPlugin(name string, replyFunc ReplyFunc, opts ...PluginOption) {
+	wrappedFunc := func(bot mplugin.Bot, e *event.MessageEvent) *mplugin.Reply {
+		return replyFunc(&botAdapter{bot}, e)
+	}
+	mplugin.RegisterReply(name, wrappedFunc, opts...)
+}
+
+// RegisterHandlerPlugin 注册支持上下文的插件，ctx 会在插件超时、连接断开或服务停止时取消
+func (mb *MiloraBot) RegisterHandlerPlugin(name string, handlerFunc HandlerFunc, opts ...PluginOption) {
+	wrappedFunc := func(ctx context.Context, bot mplugin.Bot, e *event.MessageEvent) *mplugin.Reply {
+		return handlerFunc(ctx, &botAdapter{bot}, e)
+	}
+	mplugin.RegisterHandler(name, wrappedFunc, opts...)
+}
+
+// SetPluginTimeout 设置指定插件单次调用的超时时间，覆盖全局的 PluginTimeout，0 表示不限制
+func (mb *MiloraBot) SetPluginTimeout(name string, timeout time.Duration) {
+	mplugin.SetTimeout(name, timeout)
+}
+
+// RegisterMatcherPlugin 注册声明式匹配的插件，匹配规则通过 Command、Regex、Keyword 等选项指定
+func (mb *MiloraBot) RegisterMatcherPlugin(name string, matchFunc MatchFunc, opts ...PluginOption) {
+	wrappedFunc := func(ctx context.Context, bot mplugin.Bot, e *event.MessageEvent, m *mplugin.Match) *mplugin.Reply {
+		return matchFunc(ctx, &botAdapter{bot}, e, m)
+	}
+	mplugin.RegisterMatcher(name, wrappedFunc, opts...)
+}
+
+// RegisterCommands 注册命令插件，参数自动解析校验，命令会出现在自动生成的 /help 中
+func (mb *MiloraBot) RegisterCommands(name string, commands []CommandSpec, opts ...PluginOption) {
+	mplugin.RegisterCommands(name, commands, opts...)
+}
+
+// RegisterNoticePlugin 注册通知插件（入群、退群、撤回、戳一戳等）
+func (mb *MiloraBot) RegisterNoticePlugin(name string, noticeFunc NoticeFunc) {
+	wrappedFunc := func(bot mplugin.Bot, e event.Notice) string {
+		return noticeFunc(&botAdapter{bot}, e)
+	}
+	mplugin.RegisterNotice(name, wrappedFunc)
+}
+
+// RegisterRequestPlugin 注册请求插件（加好友、加群请求），返回值会被自动转换为同意或拒绝请求的 API 调用
+func (mb *MiloraBot) RegisterRequestPlugin(name string, requestFunc RequestFunc) {
+	wrappedFunc := func(bot mplugin.Bot, e *event.RequestEvent) *mplugin.RequestReply {
+		return requestFunc(&botAdapter{bot}, e)
+	}
+	mplugin.RegisterRequest(name, wrappedFunc)
+}
+
+// RegisterMetaPlugin 注册元事件插件（生命周期、心跳）
+func (mb *MiloraBot) RegisterMetaPlugin(name string, metaFunc MetaFunc) {
+	wrappedFunc := func(bot mplugin.Bot, e event.Meta) {
+		metaFunc(&botAdapter{bot}, e)
 	}
+	mplugin.RegisterMeta(name, wrappedFunc)
+}
+
+// ResetPluginFailures 清空插件的 panic 统计，并重新启用因连续 panic 被自动禁用的插件
+func (mb *MiloraBot) ResetPluginFailures(name string) {
+	mplugin.ResetFailures(name)
+}
+
+// EnablePlugin 全局启用插件，群和用户单独的开关仍然生效
+func (mb *MiloraBot) EnablePlugin(name string) error {
+	return mplugin.SetEnabled(name, true)
+}
+
+// DisablePlugin 全局禁用插件，群和用户单独的开关仍然生效
+func (mb *MiloraBot) DisablePlugin(name string) error {
+	return mplugin.SetEnabled(name, false)
+}
+
+// SetGroupPluginEnabled 设置插件在某个群的开关
+func (mb *MiloraBot) SetGroupPluginEnabled(groupID int64, name string, enabled bool) error {
+	return mplugin.SetGroupEnabled(groupID, name, enabled)
+}
+
+// SetUserPluginEnabled 设置插件对某个用户的开关
+func (mb *MiloraBot) SetUserPluginEnabled(userID int64, name string, enabled bool) error {
+	return mplugin.SetUserEnabled(userID, name, enabled)
+}
+
+// IsPluginEnabled 判断插件对某个群或用户是否启用，groupID 为0表示私聊
+func (mb *MiloraBot) IsPluginEnabled(name string, groupID, userID int64) bool {
+	return mplugin.IsEnabled(name, groupID, userID)
+}
+
+// SetSuperusers 设置超级用户，覆盖配置中的 Superusers
+func (mb *MiloraBot) SetSuperusers(userIDs ...int64) {
+	mb.updateConfig(func(c *MiloraBotConfig) { c.Superusers = userIDs })
+	mplugin.SetSuperusers(userIDs...)
+}
+
+// GrantRole 授予用户在群中的自定义角色，groupID 为0表示在所有群生效的全局角色
+func (mb *MiloraBot) GrantRole(groupID, userID int64, role string) error {
+	return mplugin.GrantRole(groupID, userID, role)
+}
+
+// RevokeRole 撤销用户在群中的自定义角色
+func (mb *MiloraBot) RevokeRole(groupID, userID int64, role string) error {
+	return mplugin.RevokeRole(groupID, userID, role)
+}
+
+// FilterList 黑白名单类型，详见 filter 包
+type FilterList = filter.List
+
+// 黑白名单
+const (
+	AllowUsers  = filter.AllowUsers
+	DenyUsers   = filter.DenyUsers
+	AllowGroups = filter.AllowGroups
+	DenyGroups  = filter.DenyGroups
+)
+
+// AddToFilter 向黑白名单中添加用户或群，修改会保存到数据目录
+func (mb *MiloraBot) AddToFilter(list FilterList, id int64) error {
+	return filter.Add(list, id)
+}
+
+// RemoveFromFilter 从黑白名单中移除用户或群，配置中的ID需要修改配置后调用 ReloadFilter
+func (mb *MiloraBot) RemoveFromFilter(list FilterList, id int64) error {
+	return filter.Remove(list, id)
+}
+
+// ReloadFilter 重新应用配置中的黑白名单，并重新加载数据目录中的名单文件
+func (mb *MiloraBot) ReloadFilter() error {
+	config := mb.currentConfig()
+	filter.SetBase(config.filterLists())
+	return filter.Load(filepath.Join(config.DataDir, "filter.json"))
 }
 
 // SetPluginDir 设置插件目录
 func (mb *MiloraBot) SetPluginDir(dir string) {
-	mb.config.PluginDir = dir
+	mb.updateConfig(func(c *MiloraBotConfig) { c.PluginDir = dir })
 }
 
 // SetAutoLoadPlugins 设置是否自动加载插件
 func (mb *MiloraBot) SetAutoLoadPlugins(enable bool) {
-	mb.config.AutoLoadPlugins = enable
+	mb.updateConfig(func(c *MiloraBotConfig) { c.AutoLoadPlugins = enable })
 }
 
 // LoadPluginsFromDir 从指定目录加载插件
 func (mb *MiloraBot) LoadPluginsFromDir(dir string) error {
+	config := mb.currentConfig()
 	if dir == "" {
-		dir = mb.config.PluginDir
+		dir = config.PluginDir
 	}
 
 	// 检查目录是否存在
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		if mb.config.EnableLog {
-			log.Printf("📁 插件目录不存在，跳过自动加载: %s", dir)
-		}
+		logging.Logger().Info("插件目录不存在，跳过自动加载", "dir", dir)
 		return nil
 	}
 
 	// 查找插件文件
-	pattern := filepath.Join(dir, mb.config.PluginFilePattern)
+	pattern := filepath.Join(dir, config.PluginFilePattern)
 	files, err := filepath.Glob(pattern)
 	if err != nil {
 		return fmt.Errorf("查找插件文件失败: %v", err)
@@ -183,16 +544,14 @@ func (mb *MiloraBot) LoadPluginsFromDir(dir string) error {
 	loadedCount := 0
 	for _, file := range files {
 		if err := mb.loadPluginFile(file); err != nil {
-			if mb.config.EnableLog {
-				log.Printf("❌ 加载插件文件失败 %s: %v", file, err)
-			}
+			logging.Logger().Error("加载插件文件失败", "file", file, logging.KeyError, err)
 			continue
 		}
 		loadedCount++
 	}
 
-	if mb.config.EnableLog && loadedCount > 0 {
-		log.Printf("🔌 从目录 %s 加载了 %d 个插件", dir, loadedCount)
+	if loadedCount > 0 {
+		logging.Logger().Info("已从目录加载插件", "dir", dir, "count", loadedCount)
 	}
 
 	return nil
@@ -214,9 +573,7 @@ func (mb *MiloraBot) loadPluginFile(filename string) error {
 	// 调用初始化函数
 	if initFn, ok := initFunc.(func()); ok {
 		initFn()
-		if mb.config.EnableLog {
-			log.Printf("✅ 插件加载成功: %s", filepath.Base(filename))
-		}
+		logging.Logger().Info("插件加载成功", "file", filepath.Base(filename))
 	} else {
 		return fmt.Errorf("插件 %s 的 Init 函数签名不正确", filename)
 	}
@@ -226,30 +583,49 @@ func (mb *MiloraBot) loadPluginFile(filename string) error {
 
 // SetBotID 设置机器人ID
 func (mb *MiloraBot) SetBotID(botID int64) {
-	mb.config.BotID = botID
+	mb.updateConfig(func(c *MiloraBotConfig) { c.BotID = botID })
 }
 
 // SetPort 设置监听端口
 func (mb *MiloraBot) SetPort(port string) {
-	mb.config.Port = port
+	mb.updateConfig(func(c *MiloraBotConfig) { c.Port = port })
 	if mb.server != nil {
 		mb.server.Addr = port
 	}
 }
 
-// SetLogLevel 设置日志级别
+// SetLogLevel 设置日志级别（debug、info、warn、error），立即生效
 func (mb *MiloraBot) SetLogLevel(level string) {
-	mb.config.LogLevel = level
+	mb.updateConfig(func(c *MiloraBotConfig) { c.LogLevel = level })
+	if err := logging.SetLevel(level); err != nil {
+		logging.Logger().Warn("设置日志级别失败", logging.KeyError, err)
+	}
 }
 
 // EnableLogging 启用或禁用日志
 func (mb *MiloraBot) EnableLogging(enable bool) {
-	mb.config.EnableLog = enable
+	mb.updateConfig(func(c *MiloraBotConfig) {
+		c.EnableLog = enable
+		c.configureLogging()
+	})
+}
+
+// SetLogger 使用自定义的日志记录器输出框架和插件的日志，传入 nil 恢复按 LogLevel 和 LogFormat 输出
+func (mb *MiloraBot) SetLogger(l *slog.Logger) {
+	mb.updateConfig(func(c *MiloraBotConfig) {
+		c.Logger = l
+		c.configureLogging()
+	})
+}
+
+// Logger 返回框架的日志记录器
+func (mb *MiloraBot) Logger() *slog.Logger {
+	return logging.Logger()
 }
 
 // SetReadTimeout 设置读取超时
 func (mb *MiloraBot) SetReadTimeout(timeout time.Duration) {
-	mb.config.ReadTimeout = timeout
+	mb.updateConfig(func(c *MiloraBotConfig) { c.ReadTimeout = timeout })
 	if mb.server != nil {
 		mb.server.ReadTimeout = timeout
 	}
@@ -257,116 +633,207 @@ func (mb *MiloraBot) SetReadTimeout(timeout time.Duration) {
 
 // SetWriteTimeout 设置写入超时
 func (mb *MiloraBot) SetWriteTimeout(timeout time.Duration) {
-	mb.config.WriteTimeout = timeout
+	mb.updateConfig(func(c *MiloraBotConfig) { c.WriteTimeout = timeout })
 	if mb.server != nil {
 		mb.server.WriteTimeout = timeout
 	}
 }
 
-// GetConfig 获取当前配置
+// GetConfig 获取当前配置，返回的配置不应修改：请使用 Set 系列方法或 Reload 修改配置
 func (mb *MiloraBot) GetConfig() *MiloraBotConfig {
-	return mb.config
+	return mb.currentConfig()
+}
+
+// currentConfig 返回当前配置的快照，可以与配置的修改并发调用
+func (mb *MiloraBot) currentConfig() *MiloraBotConfig {
+	return mb.config.Load()
+}
+
+// updateConfig 复制当前配置，由 update 修改后整体替换，正在读取旧配置的连接不受影响
+func (mb *MiloraBot) updateConfig(update func(c *MiloraBotConfig)) {
+	mb.reloadMu.Lock()
+	defer mb.reloadMu.Unlock()
+
+	next := *mb.currentConfig()
+	update(&next)
+	mb.config.Store(&next)
+}
+
+// WebSocketHandler 返回接收 OneBot 实现反向 WebSocket 连接的处理器，连接需要通过访问令牌和 Origin 检查，
+// Start 会自动注册到 "/"，也可以挂载到自己的 HTTP 服务上
+func (mb *MiloraBot) WebSocketHandler() http.Handler {
+	return http.HandlerFunc(mb.handleWebSocket)
 }
 
-// handleWebSocket 处理WebSocket连接
+// handleWebSocket 处理OneBot实现的反向WebSocket连接
 func (mb *MiloraBot) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if !mb.authorize(w, r) {
+		return
+	}
 	conn, err := mb.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		if mb.config.EnableLog {
-			log.Printf("WebSocket升级失败: %v", err)
-		}
+		logging.Logger().Warn("WebSocket升级失败", "remote", r.RemoteAddr, logging.KeyError, err)
 		return
 	}
 	defer conn.Close()
 
-	if mb.config.EnableLog {
-		log.Println("OneBot客户端已连接！")
-	}
+	selfID, _ := strconv.ParseInt(r.Header.Get("X-Self-ID"), 10, 64)
+	logging.Logger().Info("OneBot客户端已连接", logging.KeySelfID, selfID, "remote", r.RemoteAddr)
+	mb.serveConn(mb.ctx, conn, selfID)
+}
 
-	// 创建机器人实例
-	mb.bot = &bot.Bot{
-		Conn:   conn,
-		SelfID: mb.config.BotID,
-	}
+// serveConn 读取连接上的事件并交给Bot处理，直到连接断开或 ctx 取消，反向和正向连接共用
+//
+// selfID 为连接对应的账号（来自 X-Self-ID 请求头），为0时先使用配置的 BotID，收到带有 self_id 的上报后更正
+func (mb *MiloraBot) serveConn(ctx context.Context, conn *websocket.Conn, selfID int64) {
+	// 连接的上下文：服务停止或连接断开时取消，正在运行的插件随之取消
+	connCtx, connCancel := context.WithCancel(ctx)
+	defer connCancel()
+	go func() {
+		// 服务停止时关闭连接，使阻塞中的读取立即返回
+		<-connCtx.Done()
+		conn.Close()
+	}()
+
+	// 创建机器人实例，每个连接的账号单独记录
+	config := mb.currentConfig()
+	guessed := selfID == 0
+	if guessed {
+		selfID = config.BotID
+	}
+	health := &connHealth{healthy: true}
+	b := &bot.Bot{
+		Conn:          conn,
+		SelfID:        selfID,
+		OnMeta:        health.onMeta,
+		Ctx:           connCtx,
+		PluginTimeout: config.PluginTimeout,
+	}
+	health.onConnect()
+	mb.addBot(b, health, guessed)
+
+	// 启动心跳监控
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		// 等待该连接响应的 API 调用立即失败
+		b.Close()
+		health.onDisconnect()
+		mb.removeBot(b)
+	}()
+	go mb.watchHeartbeat(conn, b, health, done)
 
 	// 消息处理循环
 	for {
 		select {
-		case <-mb.ctx.Done():
-			if mb.config.EnableLog {
-				log.Println("收到停止信号，关闭WebSocket连接")
-			}
+		case <-connCtx.Done():
+			logging.Logger().Info("收到停止信号，关闭WebSocket连接")
 			return
 		default:
 			var data map[string]interface{}
 			err := conn.ReadJSON(&data)
 			if err != nil {
-				if mb.config.EnableLog {
-					log.Printf("读取消息失败: %v", err)
-				}
+				logging.Logger().Warn("读取消息失败", logging.KeySelfID, b.GetSelfID(), logging.KeyError, err)
 				return
 			}
 
+			// 上报中的 self_id 与连接记录的账号不一致时（如正向连接、未发送 X-Self-ID），以上报为准
+			if id := api.GetInt64(data, "self_id"); id != 0 && id != b.GetSelfID() {
+				mb.rekeyBot(b, health, id)
+			}
+
 			// 交给Bot处理
-			mb.bot.HandleMessage(data)
+			b.HandleMessage(data)
 		}
 	}
 }
 
 // Start 启动MiloraBot服务
 func (mb *MiloraBot) Start() error {
+	config := mb.currentConfig()
+
 	// 自动加载插件
-	if mb.config.AutoLoadPlugins {
+	if config.AutoLoadPlugins {
 		if err := mb.LoadPluginsFromDir(""); err != nil {
-			if mb.config.EnableLog {
-				log.Printf("⚠️ 自动加载插件失败: %v", err)
-			}
+			logging.Logger().Warn("自动加载插件失败", logging.KeyError, err)
 		}
 	}
 
+	// 配置了 OneBot 实现的地址时主动连接（正向 WebSocket），同时仍然接受反向连接
+	if config.Lagrange.URL != "" {
+		go func() {
+			// 未启用重连时首次连接失败会返回错误，此后只接受反向连接
+			if err := mb.Connect(mb.ctx); err != nil {
+				logging.Logger().Error("正向 WebSocket 连接失败", "url", config.Lagrange.URL, logging.KeyError, err)
+			}
+		}()
+	}
+
+	// 名单文件被外部修改时自动重新加载
+	go filter.Watch(mb.ctx, 5*time.Second)
+
+	// 配置文件被修改或收到 SIGHUP 时重新加载配置
+	go mb.watchConfig(mb.ctx)
+	go mb.watchSignal(mb.ctx)
+
 	// 设置路由
-	http.HandleFunc("/", mb.handleWebSocket)
+	http.Handle("/", mb.WebSocketHandler())
 
-	// 健康检查端点
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+	// 健康检查端点（心跳超时后返回 503）
+	http.Handle("/health", mb.HealthHandler())
+
+	// OneBot HTTP POST 上报端点，API 通过 HTTP 调用
+	if config.HTTP.APIURL != "" {
+		mb.httpConn(config.BotID)
+		http.Handle(config.HTTP.EventPath, mb.EventHandler())
+	}
+
+	// 重新加载配置端点（只接受来自本机的 POST 请求）
+	http.HandleFunc("/reload", mb.handleReload)
 
 	// 状态信息端点
 	http.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
-		plugins := mplugin.GetPlugins()
+		plugins := mplugin.List()
+		health := mb.healthSnapshot()
+		config := mb.currentConfig()
 		status := map[string]interface{}{
-			"status":     "running",
-			"bot_id":     mb.config.BotID,
-			"port":       mb.config.Port,
-			"plugins":    len(plugins),
-			"plugin_dir": mb.config.PluginDir,
+			"status":               "running",
+			"bot_id":               config.BotID,
+			"port":                 config.Port,
+			"plugins":              len(plugins),
+			"plugin_dir":           config.PluginDir,
+			"connected":            health.Connected,
+			"bots":                 mb.BotStatuses(),
+			"healthy":              health.Healthy,
+			"send_queue":           api.GetSendQueueStats(),
+			"rejected_connections": mb.rejected.snapshot(),
+		}
+		if failures := mplugin.GetFailures(); len(failures) > 0 {
+			status["plugin_failures"] = failures
+		}
+		if !health.LastHeartbeat.IsZero() {
+			status["last_heartbeat"] = health.LastHeartbeat.Unix()
+			status["heartbeat_interval"] = health.Interval.String()
 		}
 		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprintf(w, `{"status":"%s","bot_id":%d,"port":"%s","plugins":%d}`,
-			status["status"], status["bot_id"], status["port"], status["plugins"])
+		json.NewEncoder(w).Encode(status)
 	})
 
-	if mb.config.EnableLog {
-		plugins := mplugin.GetPlugins()
-		log.Printf("MiloraBot 启动中...")
-		log.Printf("监听端口: %s", mb.config.Port)
-		log.Printf("机器人ID: %d", mb.config.BotID)
-		log.Printf("插件目录: %s", mb.config.PluginDir)
-		log.Printf("已加载插件: %d个", len(plugins))
-		log.Printf("健康检查: http://localhost%s/health", mb.config.Port)
-		log.Printf("状态信息: http://localhost%s/status", mb.config.Port)
-	}
+	logging.Logger().Info("MiloraBot 启动中",
+		"port", config.Port,
+		logging.KeySelfID, config.BotID,
+		"plugin_dir", config.PluginDir,
+		"plugins", len(mplugin.List()),
+		"health", fmt.Sprintf("http://localhost%s/health", config.Port),
+		"status", fmt.Sprintf("http://localhost%s/status", config.Port),
+	)
 
 	return mb.server.ListenAndServe()
 }
 
 // Stop 停止MiloraBot服务
 func (mb *MiloraBot) Stop(timeout time.Duration) error {
-	if mb.config.EnableLog {
-		log.Println("正在停止MiloraBot服务...")
-	}
+	logging.Logger().Info("正在停止MiloraBot服务")
 
 	// 取消上下文
 	mb.cancel()
@@ -377,31 +844,31 @@ func (mb *MiloraBot) Stop(timeout time.Duration) error {
 
 	// 优雅关闭服务器
 	err := mb.server.Shutdown(ctx)
-	if err != nil && mb.config.EnableLog {
-		log.Printf("服务器关闭失败: %v", err)
-	} else if mb.config.EnableLog {
-		log.Println("MiloraBot服务已停止")
+	if err != nil {
+		logging.Logger().Error("服务器关闭失败", logging.KeyError, err)
+	} else {
+		logging.Logger().Info("MiloraBot服务已停止")
 	}
 
 	return err
 }
 
-// GetBot 获取机器人实例（用于高级操作）
-func (mb *MiloraBot) GetBot() *bot.Bot {
-	return mb.bot
+// IsHealthy 是否有账号在线且所有账号的连接都健康（没有账号在线或心跳超时后为不健康，直到该账号重新连接）
+func (mb *MiloraBot) IsHealthy() bool {
+	return mb.healthSnapshot().Healthy
 }
 
 // GetPluginCount 获取已注册插件数量
 func (mb *MiloraBot) GetPluginCount() int {
-	return len(mplugin.GetPlugins())
+	return len(mplugin.List())
 }
 
-// ListPlugins 列出所有已注册的插件
+// ListPlugins 列出所有已注册的消息插件，按优先级排列
 func (mb *MiloraBot) ListPlugins() []string {
-	plugins := mplugin.GetPlugins()
+	plugins := mplugin.List()
 	names := make([]string, 0, len(plugins))
-	for name := range plugins {
-		names = append(names, name)
+	for _, p := range plugins {
+		names = append(names, p.Name)
 	}
 	return names
 }
@@ -414,11 +881,20 @@ func DefaultConfig() *MiloraBotConfig {
 		ReadTimeout:       15 * time.Second,
 		WriteTimeout:      15 * time.Second,
 		BotID:             123456789, // 请用户修改为自己的机器人QQ号
-		CheckOrigin:       func(r *http.Request) bool { return true },
 		EnableLog:         true,
 		LogLevel:          "info",
+		LogFormat:         "text",
 		PluginDir:         "./plugins",
 		AutoLoadPlugins:   true,
 		PluginFilePattern: "*.so",
+		PluginTimeout:     60 * time.Second,
+		MaxPluginPanics:   5,
+		APITimeout:        30 * time.Second,
+		DataDir:           "./data",
+		Security: SecurityConfig{
+			RateLimit: ratelimit.Config{Enabled: true, RequestsPerMinute: 60},
+		},
+		SendQueue:   api.SendQueueConfig{Enabled: true},
+		LongMessage: api.LongMessageConfig{Mode: api.LongMessageSplit},
 	}
 }
@@ -0,0 +1,251 @@
+package sdk
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/iamlibie/milonra-go/api"
+	"github.com/iamlibie/milonra-go/bot"
+	"github.com/iamlibie/milonra-go/logging"
+)
+
+// maxEventSize 单个上报请求体的最大长度
+const maxEventSize = 4 << 20
+
+// EventHandler 返回接收 OneBot HTTP POST 上报的处理器，插件发出的 API 调用通过 HTTP API 发送
+//
+// 配置了 http.secret 时校验 X-Signature 请求头，签名不正确时返回 401。
+// 消息和请求事件会等待最多 http.quick_reply_timeout，插件在此期间对该事件的第一条回复
+// （或对请求的处理结果）作为快速操作放在响应中返回，之后的调用仍然通过 HTTP API 发送。
+// 配置了 http.api_url 时 Start 会自动注册到 http.event_path，也可以挂载到自己的 HTTP 服务上。
+func (mb *MiloraBot) EventHandler() http.Handler {
+	return http.HandlerFunc(mb.handleEvent)
+}
+
+// handleEvent 处理一次 HTTP POST 上报
+func (mb *MiloraBot) handleEvent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxEventSize))
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	config := mb.currentConfig()
+	if secret := config.HTTP.Secret; secret != "" && !validSignature(secret, body, r.Header.Get("X-Signature")) {
+		logging.Logger().Warn("HTTP 上报签名校验失败", "remote", r.RemoteAddr)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	selfID := config.BotID
+	if id, err := strconv.ParseInt(r.Header.Get("X-Self-ID"), 10, 64); err == nil && id != 0 {
+		selfID = id
+	}
+
+	health := mb.httpConn(selfID).health
+
+	// 只有消息和请求事件支持快速操作
+	timeout := config.HTTP.QuickReplyTimeout
+	postType := api.GetString(data, "post_type")
+	if timeout < 0 || (postType != "message" && postType != "request") {
+		mb.httpBot(selfID, mb.httpTransport(), health).HandleMessage(data)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	quick := newQuickTransport(mb.httpTransport(), data)
+	mb.httpBot(selfID, quick, health).HandleMessage(data)
+
+	operation := quick.wait(timeout)
+	if operation == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(operation)
+}
+
+// httpTransport 创建通过 HTTP API 发送请求的 Transport
+func (mb *MiloraBot) httpTransport() *api.HTTPTransport {
+	config := mb.currentConfig().HTTP
+	return &api.HTTPTransport{
+		URL:         config.APIURL,
+		AccessToken: config.AccessToken,
+	}
+}
+
+// httpBot 创建通过 transport 调用 API 的机器人实例，插件的上下文由服务的上下文派生
+func (mb *MiloraBot) httpBot(selfID int64, transport bot.Transport, health *connHealth) *bot.Bot {
+	return &bot.Bot{
+		Transport:     transport,
+		SelfID:        selfID,
+		OnMeta:        health.onMeta,
+		Ctx:           mb.ctx,
+		PluginTimeout: mb.currentConfig().PluginTimeout,
+	}
+}
+
+// validSignature 校验 X-Signature 请求头，格式为 sha1=<HMAC-SHA1 十六进制>
+func validSignature(secret string, body []byte, signature string) bool {
+	const prefix = "sha1="
+	if len(signature) <= len(prefix) || signature[:len(prefix)] != prefix {
+		return false
+	}
+	got, err := hex.DecodeString(signature[len(prefix):])
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// quickTransport 在上报请求返回之前截获插件对该事件的第一条回复，作为快速操作返回，
+// 其余的 API 调用和响应返回之后的调用交给 next 发送
+type quickTransport struct {
+	next  *api.HTTPTransport
+	event map[string]interface{}
+
+	mu        sync.Mutex
+	open      bool
+	operation map[string]interface{}
+	captured  chan struct{}
+}
+
+func newQuickTransport(next *api.HTTPTransport, event map[string]interface{}) *quickTransport {
+	return &quickTransport{
+		next:     next,
+		event:    event,
+		open:     true,
+		captured: make(chan struct{}),
+	}
+}
+
+// WriteJSON 截获可以作为快速操作的 API 调用并直接返回成功的响应，否则交给 next 发送
+func (t *quickTransport) WriteJSON(v interface{}) error {
+	return t.WriteJSONContext(context.Background(), v)
+}
+
+// WriteJSONContext 与 WriteJSON 相同，交给 next 发送的请求在 ctx 取消时中止
+func (t *quickTransport) WriteJSONContext(ctx context.Context, v interface{}) error {
+	request, ok := v.(map[string]interface{})
+	if !ok {
+		return t.next.WriteJSONContext(ctx, v)
+	}
+	params, _ := request["params"].(map[string]interface{})
+	operation := quickOperation(t.event, api.GetString(request, "action"), params)
+	if operation == nil {
+		return t.next.WriteJSONContext(ctx, v)
+	}
+
+	t.mu.Lock()
+	if !t.open {
+		t.mu.Unlock()
+		return t.next.WriteJSONContext(ctx, v)
+	}
+	t.open = false
+	t.operation = operation
+	close(t.captured)
+	t.mu.Unlock()
+
+	// 快速操作没有返回值，消息ID为0
+	t.Responses().HandleAPIResponse(map[string]interface{}{
+		"status":  "ok",
+		"retcode": float64(0),
+		"data":    map[string]interface{}{},
+		"echo":    request["echo"],
+	})
+	return nil
+}
+
+// Responses 返回接收响应的等待器，与 next 共用
+func (t *quickTransport) Responses() *api.ResponseWaiter {
+	return t.next.Responses()
+}
+
+// wait 等待截获快速操作，超时后不再截获，返回截获的操作（可能为 nil）
+func (t *quickTransport) wait(timeout time.Duration) map[string]interface{} {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-t.captured:
+	case <-timer.C:
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.open = false
+	return t.operation
+}
+
+// quickOperation 将回复当前事件的 API 调用转换为 OneBot 快速操作，不能转换时返回 nil
+func quickOperation(event map[string]interface{}, action string, params map[string]interface{}) map[string]interface{} {
+	if params == nil {
+		return nil
+	}
+	switch api.GetString(event, "post_type") {
+	case "message":
+		if !repliesTo(event, action, params) {
+			return nil
+		}
+		// 回复的消息已经包含需要的引用和@，关闭默认的 at_sender
+		return map[string]interface{}{
+			"reply":       params["message"],
+			"auto_escape": false,
+			"at_sender":   false,
+		}
+	case "request":
+		if api.GetString(params, "flag") != api.GetString(event, "flag") {
+			return nil
+		}
+		switch action {
+		case "set_friend_add_request":
+			return map[string]interface{}{"approve": params["approve"], "remark": params["remark"]}
+		case "set_group_add_request":
+			return map[string]interface{}{"approve": params["approve"], "reason": params["reason"]}
+		}
+	}
+	return nil
+}
+
+// repliesTo 判断发送消息的 API 调用是否发送到消息事件所在的群聊或私聊
+func repliesTo(event map[string]interface{}, action string, params map[string]interface{}) bool {
+	if api.GetString(event, "message_type") == "group" {
+		return (action == "send_group_msg" || action == "send_msg") &&
+			paramInt64(params["group_id"]) == api.GetInt64(event, "group_id")
+	}
+	return (action == "send_private_msg" || action == "send_msg") &&
+		paramInt64(params["group_id"]) == 0 &&
+		paramInt64(params["user_id"]) == api.GetInt64(event, "user_id")
+}
+
+// paramInt64 读取 API 参数中的整数，参数由框架构造时为 int64，解析自 JSON 时为 float64
+func paramInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
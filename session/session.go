@@ -0,0 +1,214 @@
+// Package session 提供多轮对话：插件可以向用户提问，并等待同一用户在同一会话中的下一条消息
+//
+//	func WeatherPlugin(ctx context.Context, bot plugin.Bot, e *event.MessageEvent) *plugin.Reply {
+//	    city, err := session.PromptText(ctx, "想查询哪个城市？")
+//	    if err != nil {
+//	        return nil // 超时、取消或连接断开
+//	    }
+//	    return plugin.NewReply(city + " 的天气：☀️ 晴天")
+//	}
+package session
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iamlibie/milonra-go/api"
+	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/plugin"
+)
+
+var (
+	// ErrTimeout 等待用户回复超时
+	ErrTimeout = errors.New("等待用户回复超时")
+	// ErrCancelled 用户发送了取消关键词
+	ErrCancelled = errors.New("用户取消了会话")
+	// ErrBusy 该用户在当前会话中已有正在等待的提问
+	ErrBusy = errors.New("该用户已有正在等待回复的会话")
+	// ErrNoSession 上下文中没有会话
+	ErrNoSession = errors.New("上下文中没有会话")
+)
+
+// 全局设置
+var (
+	defaultTimeout = 60 * time.Second
+	cancelKeywords = []string{"取消", "cancel", "/cancel"}
+	settingsMu     sync.RWMutex
+)
+
+// SetDefaultTimeout 设置等待回复的默认超时时间，默认60秒
+func SetDefaultTimeout(timeout time.Duration) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	defaultTimeout = timeout
+}
+
+// SetCancelKeywords 设置取消会话的关键词，默认为 "取消"、"cancel" 和 "/cancel"
+func SetCancelKeywords(keywords ...string) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	cancelKeywords = append([]string(nil), keywords...)
+}
+
+// Session 一次对话，绑定触发插件的消息所在的群聊或私聊以及发送者
+type Session struct {
+	Timeout time.Duration // 每次等待回复的超时时间，0 表示使用默认值
+
+	bot   plugin.Bot
+	event *event.MessageEvent
+}
+
+// New 为触发插件的消息创建会话
+func New(bot plugin.Bot, e *event.MessageEvent) *Session {
+	return &Session{bot: bot, event: e}
+}
+
+type sessionKey struct{}
+
+// NewContext 返回携带会话的上下文，由框架在调用插件前设置
+func NewContext(ctx context.Context, bot plugin.Bot, e *event.MessageEvent) context.Context {
+	return context.WithValue(ctx, sessionKey{}, New(bot, e))
+}
+
+// FromContext 返回上下文中的会话，没有时返回 nil
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey{}).(*Session)
+	return s
+}
+
+// Prompt 使用上下文中的会话提问并等待回复，见 (*Session).Prompt
+func Prompt(ctx context.Context, prompt interface{}) (*event.MessageEvent, error) {
+	s := FromContext(ctx)
+	if s == nil {
+		return nil, ErrNoSession
+	}
+	return s.Prompt(ctx, prompt)
+}
+
+// PromptText 使用上下文中的会话提问并返回回复的纯文本，见 (*Session).PromptText
+func PromptText(ctx context.Context, prompt interface{}) (string, error) {
+	s := FromContext(ctx)
+	if s == nil {
+		return "", ErrNoSession
+	}
+	return s.PromptText(ctx, prompt)
+}
+
+// Event 返回触发会话的消息
+func (s *Session) Event() *event.MessageEvent {
+	return s.event
+}
+
+// Send 向会话所在的群聊或私聊发送消息
+func (s *Session) Send(ctx context.Context, message interface{}) error {
+	bot := plugin.WithContext(s.bot, ctx)
+	var err error
+	if s.event.GroupID != 0 {
+		_, err = api.SendGroupMessage(bot, s.event.GroupID, message)
+	} else {
+		_, err = api.SendPrivateMessage(bot, s.event.UserID, message)
+	}
+	return err
+}
+
+// Prompt 发送提问并等待同一用户在同一会话中的下一条消息
+//
+// prompt 为 nil 时只等待不发送；等待期间该用户的消息不会再分发给其他插件。
+// 超时返回 ErrTimeout，用户发送取消关键词返回 ErrCancelled，ctx 取消时返回 ctx.Err()
+func (s *Session) Prompt(ctx context.Context, prompt interface{}) (*event.MessageEvent, error) {
+	// 先开始等待再发送提问，避免用户在提问发出后立即回复时消息被当作普通消息分发
+	k := keyOf(s.event)
+	w, err := register(k)
+	if err != nil {
+		return nil, err
+	}
+	if prompt != nil {
+		if err := s.Send(ctx, prompt); err != nil {
+			// 注销时消息可能刚好送达，此时仍然使用该消息
+			if !unregister(k, w) {
+				return answer(<-w.ch)
+			}
+			return nil, err
+		}
+	}
+	return s.await(ctx, k, w)
+}
+
+// PromptText 与 Prompt 相同，返回回复的纯文本（已去除首尾空白）
+func (s *Session) PromptText(ctx context.Context, prompt interface{}) (string, error) {
+	e, err := s.Prompt(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	return messageText(e), nil
+}
+
+// Wait 等待同一用户在同一会话中的下一条消息
+func (s *Session) Wait(ctx context.Context) (*event.MessageEvent, error) {
+	k := keyOf(s.event)
+	w, err := register(k)
+	if err != nil {
+		return nil, err
+	}
+	return s.await(ctx, k, w)
+}
+
+// await 等待已注册的等待者 w 收到消息，超时或 ctx 取消时注销
+func (s *Session) await(ctx context.Context, k key, w *waiter) (*event.MessageEvent, error) {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		settingsMu.RLock()
+		timeout = defaultTimeout
+		settingsMu.RUnlock()
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case e := <-w.ch:
+		return answer(e)
+	case <-timer.C:
+		err = ErrTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	// 注销时消息可能刚好送达，此时仍然使用该消息
+	if !unregister(k, w) {
+		return answer(<-w.ch)
+	}
+	return nil, err
+}
+
+// answer 检查回复是否为取消关键词
+func answer(e *event.MessageEvent) (*event.MessageEvent, error) {
+	if isCancel(messageText(e)) {
+		return nil, ErrCancelled
+	}
+	return e, nil
+}
+
+// messageText 返回消息的纯文本，没有解析出消息段时使用原始消息
+func messageText(e *event.MessageEvent) string {
+	if len(e.Segments) == 0 {
+		return strings.TrimSpace(e.Message)
+	}
+	return strings.TrimSpace(e.PlainText())
+}
+
+// isCancel 是否为取消关键词
+func isCancel(text string) bool {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	for _, keyword := range cancelKeywords {
+		if strings.EqualFold(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iamlibie/milonra-go/api"
+	"github.com/iamlibie/milonra-go/event"
+)
+
+// promptBot answers every API call and, when the prompt is sent, delivers the user's reply
+// before the call returns, like a user answering instantly
+type promptBot struct {
+	reply *event.MessageEvent
+	err   error
+}
+
+func (b *promptBot) WriteJSON(v interface{}) error {
+	if b.err != nil {
+		return b.err
+	}
+	data := v.(map[string]interface{})
+	params, _ := data["params"].(map[string]interface{})
+	if params["message"] == "想查询哪个城市？" {
+		Deliver(b.reply)
+	}
+	api.HandleAPIResponse(map[string]interface{}{"status": "ok", "retcode": float64(0), "data": map[string]interface{}{"message_id": float64(1)}, "echo": data["echo"]})
+	return nil
+}
+
+func (b *promptBot) GetSelfID() int64 { return 123456789 }
+
+// Test a reply arriving while the prompt is still being sent goes to the session
+func TestPromptImmediateReply(t *testing.T) {
+	trigger := &event.MessageEvent{SelfID: 123456789, MessageType: "group", GroupID: 555666777, UserID: 222333555, Message: "/weather"}
+	reply := &event.MessageEvent{SelfID: 123456789, MessageType: "group", GroupID: 555666777, UserID: 222333555, Message: "上海"}
+	bot := &promptBot{reply: reply}
+
+	s := New(bot, trigger)
+	s.Timeout = time.Second
+	city, err := s.PromptText(context.Background(), "想查询哪个城市？")
+	if err != nil || city != "上海" {
+		t.Errorf("Expected answer 上海, got %q, %v", city, err)
+	}
+
+	// 提问发送失败时返回错误，并且不再等待该用户的回复
+	bot.err = errors.New("send failed")
+	if _, err := s.PromptText(context.Background(), "想查询哪个城市？"); err == nil || err.Error() != "send failed" {
+		t.Errorf("Expected send error, got %v", err)
+	}
+	if pending := Pending(); pending != 0 {
+		t.Errorf("Expected no pending sessions after a failed prompt, got %d", pending)
+	}
+}
@@ -0,0 +1,80 @@
+package session
+
+import (
+	"sync"
+
+	"github.com/iamlibie/milonra-go/event"
+)
+
+// key 会话的标识：机器人、群（私聊为0）和用户
+type key struct {
+	selfID  int64
+	groupID int64
+	userID  int64
+}
+
+func keyOf(e *event.MessageEvent) key {
+	return key{selfID: e.SelfID, groupID: e.GroupID, userID: e.UserID}
+}
+
+// waiter 正在等待回复的会话
+type waiter struct {
+	ch chan *event.MessageEvent
+}
+
+var (
+	waiters   = make(map[key]*waiter)
+	waitersMu sync.Mutex
+)
+
+// register 登记等待回复的会话，同一用户在同一会话中只能有一个
+func register(k key) (*waiter, error) {
+	waitersMu.Lock()
+	defer waitersMu.Unlock()
+
+	if _, ok := waiters[k]; ok {
+		return nil, ErrBusy
+	}
+	w := &waiter{ch: make(chan *event.MessageEvent, 1)}
+	waiters[k] = w
+	return w, nil
+}
+
+// unregister 注销会话，返回 false 表示消息已经送达
+func unregister(k key, w *waiter) bool {
+	waitersMu.Lock()
+	defer waitersMu.Unlock()
+
+	if waiters[k] != w {
+		return false
+	}
+	delete(waiters, k)
+	return true
+}
+
+// Deliver 将消息交给正在等待该用户回复的会话
+//
+// 由框架在分发消息前调用，返回 true 表示消息已被会话接收，不应再分发给插件
+func Deliver(e *event.MessageEvent) bool {
+	k := keyOf(e)
+
+	waitersMu.Lock()
+	w, ok := waiters[k]
+	if ok {
+		delete(waiters, k)
+	}
+	waitersMu.Unlock()
+
+	if !ok {
+		return false
+	}
+	w.ch <- e
+	return true
+}
+
+// Pending 返回正在等待回复的会话数量
+func Pending() int {
+	waitersMu.Lock()
+	defer waitersMu.Unlock()
+	return len(waiters)
+}
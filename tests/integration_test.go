@@ -1,12 +1,35 @@
 package integration_test
 
 import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
+
+	"github.com/iamlibie/milonra-go/api"
 	"github.com/iamlibie/milonra-go/bot"
 	"github.com/iamlibie/milonra-go/event"
+	"github.com/iamlibie/milonra-go/filter"
+	"github.com/iamlibie/milonra-go/logging"
 	"github.com/iamlibie/milonra-go/plugin"
+	"github.com/iamlibie/milonra-go/ratelimit"
+	"github.com/iamlibie/milonra-go/sdk"
+	"github.com/iamlibie/milonra-go/session"
 )
 
 // Mock WebSocket connection for testing
@@ -38,6 +61,7 @@ func TestBotMessageHandling(t *testing.T) {
 		return ""
 	}
 	plugin.Register("integration_test", testPlugin)
+	t.Cleanup(func() { plugin.Unregister("integration_test") })
 
 	// Create bot instance
 	botInstance := &bot.Bot{
@@ -83,6 +107,10 @@ func TestPluginChainExecution(t *testing.T) {
 
 	plugin.Register("chain_test_1", plugin1)
 	plugin.Register("chain_test_2", plugin2)
+	t.Cleanup(func() {
+		plugin.Unregister("chain_test_1")
+		plugin.Unregister("chain_test_2")
+	})
 
 	// Create test event
 	e := &event.MessageEvent{
@@ -107,6 +135,1059 @@ func TestPluginChainExecution(t *testing.T) {
 	}
 }
 
+// Test message events are populated from the OneBot payload
+func TestMessageEventFields(t *testing.T) {
+	received := make(chan *event.MessageEvent, 1)
+	plugin.Register("fields_test", func(bot plugin.Bot, e *event.MessageEvent) string {
+		if e.PlainText() == "fields_test" {
+			received <- e
+		}
+		return ""
+	})
+	t.Cleanup(func() { plugin.Unregister("fields_test") })
+
+	botInstance := &bot.Bot{
+		SelfID: 123456789,
+	}
+
+	botInstance.HandleMessage(map[string]interface{}{
+		"post_type":    "message",
+		"message_type": "group",
+		"sub_type":     "normal",
+		"message_id":   float64(4242),
+		"self_id":      float64(123456789),
+		"group_id":     float64(987654321),
+		"user_id":      float64(111222333),
+		"message": []interface{}{
+			map[string]interface{}{"type": "at", "data": map[string]interface{}{"qq": "123456789"}},
+			map[string]interface{}{"type": "text", "data": map[string]interface{}{"text": "fields_test"}},
+		},
+		"raw_message": "[CQ:at,qq=123456789]fields_test",
+		"sender": map[string]interface{}{
+			"user_id":  float64(111222333),
+			"nickname": "tester",
+			"card":     "card",
+			"role":     "admin",
+		},
+		"time": float64(time.Now().Unix()),
+	})
+
+	select {
+	case e := <-received:
+		if e.MessageID != 4242 || e.MessageType != "group" || e.SubType != "normal" || e.SelfID != 123456789 {
+			t.Errorf("Unexpected message fields: %+v", e)
+		}
+		if e.Nickname != "tester" || !e.Sender.IsAdmin() || e.Sender.DisplayName() != "card" {
+			t.Errorf("Unexpected sender: %+v", e.Sender)
+		}
+		if len(e.Segments) != 2 || e.Segments[0].Type != "at" {
+			t.Errorf("Unexpected segments: %+v", e.Segments)
+		}
+		if !e.IsAtMe {
+			t.Error("Expected IsAtMe to be true")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Plugin was not called")
+	}
+}
+
+// Test panicking plugins are isolated, counted and auto-disabled
+func TestPluginPanicIsolation(t *testing.T) {
+	plugin.SetMaxPanics(2)
+	defer plugin.SetMaxPanics(5)
+
+	plugin.Register("panic_test", func(bot plugin.Bot, e *event.MessageEvent) string {
+		if e.Message == "panic_test" {
+			panic("boom")
+		}
+		return ""
+	})
+	t.Cleanup(func() {
+		plugin.Unregister("panic_test")
+		plugin.ResetFailures("panic_test")
+	})
+
+	botInstance := &bot.Bot{
+		SelfID: 123456789,
+	}
+	messageData := map[string]interface{}{
+		"post_type":    "message",
+		"message_type": "private",
+		"user_id":      float64(111222333),
+		"message":      "panic_test",
+		"time":         float64(time.Now().Unix()),
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if plugin.IsFailureDisabled("panic_test") {
+			break
+		}
+		botInstance.HandleMessage(messageData)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stats, ok := plugin.GetFailures()["panic_test"]
+	if !ok {
+		t.Fatal("Expected failure stats for panic_test")
+	}
+	if stats.Panics < 2 || !stats.Disabled || stats.LastPanic != "boom" {
+		t.Errorf("Unexpected failure stats: %+v", stats)
+	}
+}
+
+// Test plugins run in priority order and a handled message blocks lower priorities
+func TestPluginPriorityBlock(t *testing.T) {
+	called := make(chan string, 4)
+	plugin.RegisterReply("priority_low", func(bot plugin.Bot, e *event.MessageEvent) *plugin.Reply {
+		if e.Message == "priority_test" {
+			called <- "low"
+		}
+		return nil
+	}, plugin.WithPriority(200))
+	plugin.RegisterReply("priority_high", func(bot plugin.Bot, e *event.MessageEvent) *plugin.Reply {
+		if e.Message != "priority_test" {
+			return nil
+		}
+		called <- "high"
+		return plugin.NewReply()
+	}, plugin.WithPriority(1), plugin.WithBlock())
+	defer plugin.Unregister("priority_low")
+	defer plugin.Unregister("priority_high")
+
+	list := plugin.List()
+	if len(list) == 0 || list[0].Name != "priority_high" {
+		t.Fatalf("Expected priority_high to be first, got %+v", list)
+	}
+
+	botInstance := &bot.Bot{
+		SelfID: 123456789,
+	}
+	botInstance.HandleMessage(map[string]interface{}{
+		"post_type":    "message",
+		"message_type": "private",
+		"user_id":      float64(111222333),
+		"message":      "priority_test",
+		"time":         float64(time.Now().Unix()),
+	})
+
+	select {
+	case name := <-called:
+		if name != "high" {
+			t.Fatalf("Expected high priority plugin first, got %s", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for high priority plugin")
+	}
+
+	select {
+	case name := <-called:
+		t.Errorf("Expected %s not to be called after a blocking plugin handled the message", name)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMatcherDispatch(t *testing.T) {
+	args := make(chan []string, 2)
+	plugin.RegisterMatcher("matcher_dispatch", func(ctx context.Context, bot plugin.Bot, e *event.MessageEvent, m *plugin.Match) *plugin.Reply {
+		args <- m.Fields
+		return nil
+	}, plugin.Command("matchtest"), plugin.PrivateOnly())
+	defer plugin.Unregister("matcher_dispatch")
+
+	botInstance := &bot.Bot{
+		SelfID: 123456789,
+	}
+	botInstance.HandleMessage(map[string]interface{}{
+		"post_type":    "message",
+		"message_type": "private",
+		"user_id":      float64(111222333),
+		"message":      "/matchtest foo bar",
+		"time":         float64(time.Now().Unix()),
+	})
+
+	select {
+	case fields := <-args:
+		if len(fields) != 2 || fields[0] != "foo" || fields[1] != "bar" {
+			t.Errorf("Unexpected args: %v", fields)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for matcher plugin")
+	}
+
+	botInstance.HandleMessage(map[string]interface{}{
+		"post_type":    "message",
+		"message_type": "private",
+		"user_id":      float64(111222333),
+		"message":      "matchtest foo",
+		"time":         float64(time.Now().Unix()),
+	})
+	select {
+	case fields := <-args:
+		t.Errorf("Expected unprefixed command not to match, got %v", fields)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSessionPrompt(t *testing.T) {
+	answers := make(chan string, 2)
+	others := make(chan string, 4)
+	plugin.RegisterMatcher("session_test", func(ctx context.Context, bot plugin.Bot, e *event.MessageEvent, m *plugin.Match) *plugin.Reply {
+		reply, err := session.FromContext(ctx).Wait(ctx)
+		if err != nil {
+			answers <- err.Error()
+			return nil
+		}
+		answers <- reply.Message
+		return nil
+	}, plugin.Command("session_test"))
+	plugin.Register("session_other", func(bot plugin.Bot, e *event.MessageEvent) string {
+		if e.UserID == 222333444 {
+			others <- e.Message
+		}
+		return ""
+	})
+	defer plugin.Unregister("session_test")
+	defer plugin.Unregister("session_other")
+
+	botInstance := &bot.Bot{
+		SelfID: 123456789,
+	}
+	send := func(message string) {
+		botInstance.HandleMessage(map[string]interface{}{
+			"post_type":    "message",
+			"message_type": "group",
+			"group_id":     float64(555666777),
+			"user_id":      float64(222333444),
+			"message":      message,
+			"time":         float64(time.Now().Unix()),
+		})
+	}
+	waitPending := func() {
+		deadline := time.Now().Add(time.Second)
+		for session.Pending() == 0 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	send("/session_test")
+	<-others
+	waitPending()
+	send("北京")
+	if answer := <-answers; answer != "北京" {
+		t.Errorf("Expected answer 北京, got %q", answer)
+	}
+	select {
+	case msg := <-others:
+		t.Errorf("Expected answer not to reach other plugins, got %q", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	send("/session_test")
+	<-others
+	waitPending()
+	send("取消")
+	if answer := <-answers; answer != session.ErrCancelled.Error() {
+		t.Errorf("Expected cancellation, got %q", answer)
+	}
+}
+
+// Test messages from filtered users are dropped before dispatch
+func TestFilterDispatch(t *testing.T) {
+	filter.SetBase(filter.Lists{DenyUsers: []int64{666}})
+	defer filter.SetBase(filter.Lists{})
+
+	called := make(chan struct{}, 1)
+	plugin.Register("filter_test", func(bot plugin.Bot, e *event.MessageEvent) string {
+		if e.Message == "filter_test" {
+			called <- struct{}{}
+		}
+		return ""
+	})
+	defer plugin.Unregister("filter_test")
+	botInstance := &bot.Bot{SelfID: 123456789}
+	botInstance.HandleMessage(map[string]interface{}{
+		"post_type":    "message",
+		"message_type": "private",
+		"user_id":      float64(666),
+		"message":      "filter_test",
+		"time":         float64(time.Now().Unix()),
+	})
+	select {
+	case <-called:
+		t.Error("Expected message from denied user not to be dispatched")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRateLimitConcurrentDispatch(t *testing.T) {
+	ratelimit.Configure(ratelimit.Config{Enabled: true, RequestsPerMinute: 2, Silent: true})
+	defer ratelimit.Configure(ratelimit.Config{})
+
+	var handled atomic.Int32
+	plugin.RegisterMatcher("rate_concurrent", func(ctx context.Context, bot plugin.Bot, e *event.MessageEvent, m *plugin.Match) *plugin.Reply {
+		if len(m.Fields) > 0 && m.Fields[0] == "skip" {
+			// 没有处理的消息不消耗次数
+			return nil
+		}
+		handled.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return plugin.NewReply("ok")
+	}, plugin.Command("ratetest"))
+	defer plugin.Unregister("rate_concurrent")
+
+	botInstance := &bot.Bot{SelfID: 123456789}
+	send := func(message string) {
+		botInstance.HandleMessage(map[string]interface{}{
+			"post_type":    "message",
+			"message_type": "private",
+			"user_id":      float64(424242),
+			"message":      message,
+			"time":         float64(time.Now().Unix()),
+		})
+	}
+
+	send("/ratetest skip")
+	send("/ratetest skip")
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < 10; i++ {
+		go send("/ratetest")
+	}
+	time.Sleep(200 * time.Millisecond)
+	if n := handled.Load(); n != 2 {
+		t.Errorf("Expected 2 of 10 concurrent messages to reach the handler, got %d", n)
+	}
+}
+
+// Test the forward WebSocket client against a fake OneBot implementation
+func TestForwardWebSocket(t *testing.T) {
+	type action struct {
+		Action string                 `json:"action"`
+		Params map[string]interface{} `json:"params"`
+		Echo   string                 `json:"echo"`
+	}
+	tokens := make(chan string, 4)
+	actions := make(chan action, 4)
+	connections := make(chan int, 4)
+	var count atomic.Int32
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens <- r.Header.Get("Authorization")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		n := int(count.Add(1))
+		connections <- n
+		if n > 1 {
+			// 重连后保持连接直到客户端关闭
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}
+
+		// 第一次连接：上报一条消息，响应插件发出的 API 调用后断开
+		conn.WriteJSON(map[string]interface{}{
+			"post_type":    "message",
+			"message_type": "private",
+			"self_id":      float64(123456789),
+			"user_id":      float64(111222333),
+			"message":      "/fwtest",
+			"time":         float64(time.Now().Unix()),
+		})
+		var a action
+		if err := conn.ReadJSON(&a); err != nil {
+			return
+		}
+		actions <- a
+		conn.WriteJSON(map[string]interface{}{"status": "ok", "retcode": 0, "data": map[string]interface{}{"message_id": 1}, "echo": a.Echo})
+	}))
+	defer server.Close()
+
+	plugin.RegisterMatcher("forward_test", func(ctx context.Context, bot plugin.Bot, e *event.MessageEvent, m *plugin.Match) *plugin.Reply {
+		return plugin.NewReply("pong")
+	}, plugin.Command("fwtest"))
+	defer plugin.Unregister("forward_test")
+
+	dir := t.TempDir()
+	mb := sdk.NewMiloraBot(&sdk.MiloraBotConfig{
+		BotID:   123456789,
+		DataDir: dir,
+		Lagrange: sdk.LagrangeConfig{
+			URL:               "ws" + strings.TrimPrefix(server.URL, "http"),
+			AccessToken:       "secret",
+			Reconnect:         true,
+			ReconnectInterval: 10 * time.Millisecond,
+		},
+	})
+	defer func() {
+		plugin.LoadSwitches(filepath.Join(t.TempDir(), "empty.json"))
+		plugin.LoadRoles(filepath.Join(t.TempDir(), "empty.json"))
+		filter.Load(filepath.Join(t.TempDir(), "empty.json"))
+		ratelimit.Configure(ratelimit.Config{})
+		api.ConfigureSendQueue(api.SendQueueConfig{})
+		api.ConfigureLongMessage(api.LongMessageConfig{})
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- mb.Connect(ctx) }()
+
+	select {
+	case token := <-tokens:
+		if token != "Bearer secret" {
+			t.Errorf("Expected access token header, got %q", token)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Client did not connect")
+	}
+	select {
+	case a := <-actions:
+		if a.Action != "send_private_msg" || a.Params["message"] != "pong" {
+			t.Errorf("Unexpected action: %+v", a)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Plugin reply was not sent over the forward connection")
+	}
+
+	// 服务端断开后自动重连
+	deadline := time.After(2 * time.Second)
+	for reconnected := false; !reconnected; {
+		select {
+		case n := <-connections:
+			reconnected = n == 2
+		case <-deadline:
+			t.Fatal("Client did not reconnect")
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("Expected nil after cancel, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Connect did not return after cancel")
+	}
+
+	// 未启用重连时，连接失败直接返回错误
+	server.Close()
+	once := sdk.NewMiloraBot(&sdk.MiloraBotConfig{
+		DataDir:  dir,
+		Lagrange: sdk.LagrangeConfig{URL: "ws" + strings.TrimPrefix(server.URL, "http")},
+	})
+	if err := once.Connect(context.Background()); err == nil {
+		t.Error("Expected dial error without reconnect")
+	}
+}
+
+// Test HTTP POST events with signature verification, quick operations and HTTP API calls
+func TestHTTPWebhook(t *testing.T) {
+	type call struct {
+		path   string
+		params map[string]interface{}
+	}
+	calls := make(chan call, 4)
+	onebot := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&params)
+		calls <- call{r.URL.Path, params}
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "retcode": 0, "data": map[string]interface{}{"message_id": 7}})
+	}))
+	defer onebot.Close()
+
+	plugin.RegisterMatcher("webhook_test", func(ctx context.Context, bot plugin.Bot, e *event.MessageEvent, m *plugin.Match) *plugin.Reply {
+		return plugin.NewReply("first", "second")
+	}, plugin.Command("hooktest"))
+	defer plugin.Unregister("webhook_test")
+
+	dir := t.TempDir()
+	mb := sdk.NewMiloraBot(&sdk.MiloraBotConfig{
+		BotID:   123456789,
+		DataDir: dir,
+		HTTP: sdk.HTTPConfig{
+			APIURL:            onebot.URL,
+			Secret:            "s3cret",
+			QuickReplyTimeout: time.Second,
+		},
+	})
+	defer func() {
+		plugin.LoadSwitches(filepath.Join(t.TempDir(), "empty.json"))
+		plugin.LoadRoles(filepath.Join(t.TempDir(), "empty.json"))
+		filter.Load(filepath.Join(t.TempDir(), "empty.json"))
+		ratelimit.Configure(ratelimit.Config{})
+		api.ConfigureSendQueue(api.SendQueueConfig{})
+		api.ConfigureLongMessage(api.LongMessageConfig{})
+	}()
+	server := httptest.NewServer(mb.EventHandler())
+	defer server.Close()
+
+	post := func(body, signature string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Self-ID", "123456789")
+		if signature != "" {
+			req.Header.Set("X-Signature", signature)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		return resp
+	}
+	sign := func(body string) string {
+		mac := hmac.New(sha1.New, []byte("s3cret"))
+		mac.Write([]byte(body))
+		return "sha1=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	body := `{"post_type": "message", "message_type": "group", "group_id": 555666777, "user_id": 111222333, "message": "/hooktest", "time": 1700000000}`
+
+	// 签名不正确时拒绝
+	resp := post(body, "sha1=0000")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for bad signature, got %d", resp.StatusCode)
+	}
+
+	// 第一条回复作为快速操作返回，第二条通过 HTTP API 发送
+	resp = post(body, sign(body))
+	var operation map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&operation)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || operation["reply"] != "first" || operation["at_sender"] != false {
+		t.Errorf("Unexpected quick operation: %d %v", resp.StatusCode, operation)
+	}
+	select {
+	case c := <-calls:
+		if c.path != "/send_group_msg" || c.params["message"] != "second" || c.params["group_id"] != float64(555666777) {
+			t.Errorf("Unexpected API call: %+v", c)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Second reply was not sent through the HTTP API")
+	}
+
+	// 没有插件回复的事件返回 204
+	body = `{"post_type": "notice", "notice_type": "group_increase", "group_id": 555666777, "user_id": 1, "time": 1700000000}`
+	resp = post(body, sign(body))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected 204 for notice, got %d", resp.StatusCode)
+	}
+}
+
+// Test access token and origin checks on the reverse WebSocket endpoint
+func TestReverseWebSocketAuth(t *testing.T) {
+	dir := t.TempDir()
+	mb := sdk.NewMiloraBot(&sdk.MiloraBotConfig{
+		BotID:   123456789,
+		DataDir: dir,
+		Security: sdk.SecurityConfig{
+			AccessToken:    "secret",
+			AllowedOrigins: []string{"https://panel.example.com"},
+		},
+	})
+	defer func() {
+		plugin.LoadSwitches(filepath.Join(t.TempDir(), "empty.json"))
+		plugin.LoadRoles(filepath.Join(t.TempDir(), "empty.json"))
+		filter.Load(filepath.Join(t.TempDir(), "empty.json"))
+		ratelimit.Configure(ratelimit.Config{})
+		api.ConfigureSendQueue(api.SendQueueConfig{})
+		api.ConfigureLongMessage(api.LongMessageConfig{})
+	}()
+	server := httptest.NewServer(mb.WebSocketHandler())
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/"
+
+	tests := []struct {
+		name   string
+		query  string
+		header http.Header
+		status int
+	}{
+		{"missing token", "", nil, http.StatusUnauthorized},
+		{"wrong token", "", http.Header{"Authorization": {"Bearer wrong"}}, http.StatusForbidden},
+		{"bearer token", "", http.Header{"Authorization": {"Bearer secret"}}, http.StatusSwitchingProtocols},
+		{"query token", "?access_token=secret", nil, http.StatusSwitchingProtocols},
+		{"wrong query token", "?access_token=nope", nil, http.StatusForbidden},
+		{"disallowed origin", "", http.Header{"Authorization": {"Bearer secret"}, "Origin": {"https://evil.example.com"}}, http.StatusForbidden},
+		{"allowed origin", "", http.Header{"Authorization": {"Bearer secret"}, "Origin": {"https://panel.example.com"}}, http.StatusSwitchingProtocols},
+	}
+	for _, tt := range tests {
+		conn, resp, err := websocket.DefaultDialer.Dial(wsURL+tt.query, tt.header)
+		if conn != nil {
+			conn.Close()
+		}
+		if resp == nil {
+			t.Errorf("%s: no response: %v", tt.name, err)
+			continue
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.status)
+		}
+	}
+
+	rejected := mb.RejectedConnections()
+	if rejected["token"] != 3 || rejected["origin"] != 1 {
+		t.Errorf("Unexpected rejection counts: %v", rejected)
+	}
+}
+
+// fakeOneBot is a reverse WebSocket client acting as one OneBot account
+type fakeOneBot struct {
+	conn    *websocket.Conn
+	actions chan map[string]interface{}
+	writeMu sync.Mutex // 测试和自动响应的 goroutine 都会写入连接
+}
+
+func dialFakeOneBot(t *testing.T, url string, selfID string) *fakeOneBot {
+	header := http.Header{}
+	if selfID != "" {
+		header.Set("X-Self-ID", selfID)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	f := &fakeOneBot{conn: conn, actions: make(chan map[string]interface{}, 8)}
+	go func() {
+		for {
+			var action map[string]interface{}
+			if err := conn.ReadJSON(&action); err != nil {
+				return
+			}
+			f.actions <- action
+			f.send(map[string]interface{}{"status": "ok", "retcode": 0, "data": map[string]interface{}{"message_id": 1}, "echo": action["echo"]})
+		}
+	}()
+	return f
+}
+
+// send writes v to the connection, serialized with the automatic responses
+func (f *fakeOneBot) send(v interface{}) error {
+	f.writeMu.Lock()
+	defer f.writeMu.Unlock()
+	return f.conn.WriteJSON(v)
+}
+
+func (f *fakeOneBot) expectAction(t *testing.T, name string) map[string]interface{} {
+	t.Helper()
+	select {
+	case action := <-f.actions:
+		return action
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%s: timed out waiting for action", name)
+		return nil
+	}
+}
+
+// Test several accounts connected at once, each replying through its own connection
+func TestMultipleBots(t *testing.T) {
+	plugin.RegisterMatcher("multi_bot_test", func(ctx context.Context, bot plugin.Bot, e *event.MessageEvent, m *plugin.Match) *plugin.Reply {
+		if len(m.Fields) > 0 && m.Fields[0] == "relay" {
+			// 改用另一个账号发送
+			other, ok := plugin.SwitchBot(bot, 10001)
+			if !ok {
+				return plugin.NewReply("offline")
+			}
+			api.SendPrivateMessage(other, e.UserID, "relayed")
+			return nil
+		}
+		return plugin.NewReply(fmt.Sprintf("from %d", bot.GetSelfID()))
+	}, plugin.Command("multitest"))
+	defer plugin.Unregister("multi_bot_test")
+
+	dir := t.TempDir()
+	mb := sdk.NewMiloraBot(&sdk.MiloraBotConfig{BotID: 10001, DataDir: dir})
+	defer func() {
+		plugin.LoadSwitches(filepath.Join(t.TempDir(), "empty.json"))
+		plugin.LoadRoles(filepath.Join(t.TempDir(), "empty.json"))
+		filter.Load(filepath.Join(t.TempDir(), "empty.json"))
+		ratelimit.Configure(ratelimit.Config{})
+		api.ConfigureSendQueue(api.SendQueueConfig{})
+		api.ConfigureLongMessage(api.LongMessageConfig{})
+	}()
+	server := httptest.NewServer(mb.WebSocketHandler())
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/"
+
+	first := dialFakeOneBot(t, wsURL, "10001")
+	defer first.conn.Close()
+	second := dialFakeOneBot(t, wsURL, "10002")
+	defer second.conn.Close()
+	// 不发送 X-Self-ID 的连接通过生命周期事件识别账号
+	third := dialFakeOneBot(t, wsURL, "")
+	defer third.conn.Close()
+	third.send(map[string]interface{}{
+		"post_type": "meta_event", "meta_event_type": "lifecycle", "sub_type": "connect",
+		"self_id": float64(10003), "time": float64(time.Now().Unix()),
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(mb.Bots()) < 3 || mb.GetBot(10003) == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 3 bots, got %d", len(mb.Bots()))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if b := mb.GetBot(10002); b == nil || b.GetSelfID() != 10002 {
+		t.Errorf("GetBot(10002) = %v", b)
+	}
+	if b, ok := plugin.GetBot(10003); !ok || b.GetSelfID() != 10003 {
+		t.Error("Expected bot identified by lifecycle event to be registered")
+	}
+
+	message := func(f *fakeOneBot, selfID float64, text string) {
+		f.send(map[string]interface{}{
+			"post_type": "message", "message_type": "private", "self_id": selfID,
+			"user_id": float64(111222333), "message": text, "time": float64(time.Now().Unix()),
+		})
+	}
+
+	// 回复通过收到消息的连接发送
+	message(second, 10002, "/multitest")
+	action := second.expectAction(t, "reply")
+	if params := action["params"].(map[string]interface{}); params["message"] != "from 10002" {
+		t.Errorf("Unexpected reply: %v", params)
+	}
+
+	// 插件可以选择另一个账号发送
+	message(second, 10002, "/multitest relay")
+	action = first.expectAction(t, "relay")
+	if params := action["params"].(map[string]interface{}); action["action"] != "send_private_msg" || params["message"] != "relayed" {
+		t.Errorf("Unexpected relayed action: %v", action)
+	}
+	select {
+	case action := <-second.actions:
+		t.Errorf("Expected nothing on the receiving connection, got %v", action)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// 断开后账号下线
+	second.conn.Close()
+	deadline = time.Now().Add(2 * time.Second)
+	for mb.GetBot(10002) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected bot 10002 to go offline")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, ok := plugin.GetBot(10002); ok {
+		t.Error("Expected bot 10002 to be unregistered")
+	}
+}
+
+func TestPendingCallsFailOnDisconnect(t *testing.T) {
+	dir := t.TempDir()
+	mb := sdk.NewMiloraBot(&sdk.MiloraBotConfig{BotID: 10001, DataDir: dir})
+	defer func() {
+		plugin.LoadSwitches(filepath.Join(t.TempDir(), "empty.json"))
+		plugin.LoadRoles(filepath.Join(t.TempDir(), "empty.json"))
+		filter.Load(filepath.Join(t.TempDir(), "empty.json"))
+		ratelimit.Configure(ratelimit.Config{})
+		api.ConfigureSendQueue(api.SendQueueConfig{})
+		api.ConfigureLongMessage(api.LongMessageConfig{})
+	}()
+	server := httptest.NewServer(mb.WebSocketHandler())
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/"
+
+	// 两个只读取请求、不自动响应的连接
+	dial := func(selfID string) (*websocket.Conn, chan map[string]interface{}) {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"X-Self-ID": {selfID}})
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		actions := make(chan map[string]interface{}, 8)
+		go func() {
+			for {
+				var action map[string]interface{}
+				if err := conn.ReadJSON(&action); err != nil {
+					return
+				}
+				actions <- action
+			}
+		}()
+		return conn, actions
+	}
+	first, firstActions := dial("10001")
+	defer first.Close()
+	second, _ := dial("10002")
+	defer second.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for mb.GetBot(10001) == nil || mb.GetBot(10002) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected both bots to be online")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := api.GetLoginInfo(mb.GetBot(10001))
+		result <- err
+	}()
+
+	var echo interface{}
+	select {
+	case action := <-firstActions:
+		echo = action["echo"]
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the API request")
+	}
+
+	// 另一个连接上带有相同 echo 的响应不会交给该调用
+	second.WriteJSON(map[string]interface{}{"status": "ok", "retcode": 0, "data": map[string]interface{}{}, "echo": echo})
+	select {
+	case err := <-result:
+		t.Fatalf("Call completed by a response on another connection: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// 连接断开后等待中的调用立即失败，而不是等到超时
+	first.Close()
+	select {
+	case err := <-result:
+		if !errors.Is(err, api.ErrConnectionClosed) {
+			t.Errorf("Expected api.ErrConnectionClosed, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Pending call was not failed when the connection closed")
+	}
+}
+
+// safeBuffer 可并发写入的缓冲区，用于收集日志
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// entries 解析收集到的 JSON 日志
+func (b *safeBuffer) entries(t *testing.T) []map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Invalid JSON log line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Test framework and plugin logs go through the injected slog logger with event fields
+func TestStructuredLogging(t *testing.T) {
+	// 在替换记录器之前创建的插件记录器同样输出到新的记录器
+	early := plugin.Logger("log_early")
+
+	var out safeBuffer
+	logging.SetLogger(slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer logging.SetLogger(nil)
+
+	done := make(chan struct{})
+	plugin.RegisterMatcher("log_test", func(ctx context.Context, bot plugin.Bot, e *event.MessageEvent, m *plugin.Match) *plugin.Reply {
+		plugin.LoggerOf(ctx).Info("plugin says hi", "city", m.Fields[0])
+		close(done)
+		return nil
+	}, plugin.Command("logtest"))
+	defer plugin.Unregister("log_test")
+
+	botInstance := &bot.Bot{SelfID: 123456789}
+	botInstance.HandleMessage(map[string]interface{}{
+		"post_type":    "message",
+		"message_type": "group",
+		"group_id":     float64(555666777),
+		"user_id":      float64(111222333),
+		"message":      "/logtest beijing",
+		"time":         float64(time.Now().Unix()),
+	})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for plugin")
+	}
+	early.Warn("early logger")
+
+	var pluginEntry, received, earlyEntry map[string]interface{}
+	for _, entry := range out.entries(t) {
+		switch entry["msg"] {
+		case "plugin says hi":
+			pluginEntry = entry
+		case "收到消息":
+			received = entry
+		case "early logger":
+			earlyEntry = entry
+		}
+	}
+	if pluginEntry == nil {
+		t.Fatal("Plugin log was not written to the injected logger")
+	}
+	want := map[string]interface{}{
+		logging.KeyPlugin:  "log_test",
+		logging.KeySelfID:  float64(123456789),
+		logging.KeyGroupID: float64(555666777),
+		logging.KeyUserID:  float64(111222333),
+		"city":             "beijing",
+		"level":            "INFO",
+	}
+	for key, value := range want {
+		if pluginEntry[key] != value {
+			t.Errorf("Plugin log field %s = %v, want %v", key, pluginEntry[key], value)
+		}
+	}
+	if received == nil || received[logging.KeyGroupID] != float64(555666777) || received["message"] != "/logtest beijing" {
+		t.Errorf("Unexpected received message log: %v", received)
+	}
+	if earlyEntry == nil || earlyEntry[logging.KeyPlugin] != "log_early" {
+		t.Errorf("Logger created before SetLogger was not forwarded: %v", earlyEntry)
+	}
+}
+
+// Test notice events are parsed into typed structs and dispatched
+func TestNoticeDispatch(t *testing.T) {
+	received := make(chan event.Notice, 1)
+	plugin.RegisterNotice("notice_test", func(bot plugin.Bot, e event.Notice) string {
+		if e.Base().GroupID == 555666777 {
+			received <- e
+		}
+		return ""
+	})
+
+	botInstance := &bot.Bot{
+		SelfID: 123456789,
+	}
+
+	botInstance.HandleMessage(map[string]interface{}{
+		"post_type":   "notice",
+		"notice_type": "group_increase",
+		"sub_type":    "invite",
+		"group_id":    float64(555666777),
+		"user_id":     float64(111222333),
+		"operator_id": float64(444555666),
+		"self_id":     float64(123456789),
+		"time":        float64(time.Now().Unix()),
+	})
+
+	select {
+	case e := <-received:
+		n, ok := e.(*event.GroupIncreaseNotice)
+		if !ok {
+			t.Fatalf("Expected *event.GroupIncreaseNotice, got %T", e)
+		}
+		if n.UserID != 111222333 || n.OperatorID != 444555666 || n.SubType != "invite" {
+			t.Errorf("Unexpected notice fields: %+v", n)
+		}
+		if n.RawData == nil {
+			t.Error("Expected RawData to be set")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Notice plugin was not called")
+	}
+}
+
+// Test request events are parsed and dispatched to request plugins
+func TestRequestDispatch(t *testing.T) {
+	received := make(chan *event.RequestEvent, 1)
+	plugin.RegisterRequest("request_test", func(bot plugin.Bot, e *event.RequestEvent) *plugin.RequestReply {
+		if e.Flag == "request_test_flag" {
+			received <- e
+		}
+		return nil
+	})
+
+	botInstance := &bot.Bot{
+		SelfID: 123456789,
+	}
+
+	botInstance.HandleMessage(map[string]interface{}{
+		"post_type":    "request",
+		"request_type": "group",
+		"sub_type":     "add",
+		"group_id":     float64(555666777),
+		"user_id":      float64(111222333),
+		"comment":      "let me in",
+		"flag":         "request_test_flag",
+		"time":         float64(time.Now().Unix()),
+	})
+
+	select {
+	case e := <-received:
+		if !e.IsGroup() || e.SubType != "add" || e.Comment != "let me in" || e.GroupID != 555666777 {
+			t.Errorf("Unexpected request fields: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Request plugin was not called")
+	}
+}
+
+// Test request plugins run in registration order and the first result wins
+func TestRequestPluginOrder(t *testing.T) {
+	called := make(chan string, 10)
+	names := []string{"request_order_1", "request_order_2", "request_order_3"}
+	for _, name := range names {
+		name := name
+		plugin.RegisterRequest(name, func(bot plugin.Bot, e *event.RequestEvent) *plugin.RequestReply {
+			if e.Flag != "request_order_flag" {
+				return nil
+			}
+			called <- name
+			if name == "request_order_1" {
+				return nil
+			}
+			return plugin.Approve("")
+		})
+		t.Cleanup(func() { plugin.Unregister(name) })
+	}
+
+	botInstance := &bot.Bot{
+		SelfID: 123456789,
+	}
+
+	for i := 0; i < 20; i++ {
+		botInstance.HandleMessage(map[string]interface{}{
+			"post_type":    "request",
+			"request_type": "friend",
+			"user_id":      float64(111222333),
+			"flag":         "request_order_flag",
+			"time":         float64(time.Now().Unix()),
+		})
+
+		for _, want := range names[:2] {
+			select {
+			case got := <-called:
+				if got != want {
+					t.Fatalf("Expected %s to be called, got %s", want, got)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Request plugin %s was not called", want)
+			}
+		}
+	}
+
+	select {
+	case got := <-called:
+		t.Errorf("Expected no plugin after the first result, got %s", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 // Benchmark plugin execution
 func BenchmarkPluginExecution(b *testing.B) {
 	testPlugin := func(bot plugin.Bot, e *event.MessageEvent) string {